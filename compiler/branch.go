@@ -0,0 +1,347 @@
+package compiler
+
+import (
+	py "github.com/mbergin/gotopython/pythonast"
+	"go/ast"
+	"go/token"
+	"go/types"
+	"strconv"
+	"unicode"
+)
+
+// A loop is a Python loop enclosing the statement being compiled. Python can
+// only break or continue the innermost loop, so a branch to an outer loop
+// sets a flag and breaks; each loop in between tests the flag after it ends
+// and passes the branch on.
+//
+// The body of a range-over-func loop is a Python function instead, which
+// returns False to break the loop and True to continue it.
+type loop struct {
+	stmt         ast.Stmt // the Go loop, switch or select, the function body, or nil for a goto block
+	breakFlag    *py.Name
+	continueFlag *py.Name
+	exits        []exit    // branches out of this loop to enclosing loops
+	post         []py.Stmt // statements that run before each iteration after the first
+
+	isFunc    bool            // the body is a function called by a range-over-func iterator
+	returns   bool            // the body returns from the function being compiled
+	nonlocals []py.Identifier // variables outside the body that the body assigns
+}
+
+type exit struct {
+	flag   *py.Name
+	target *loop
+	cont   bool
+}
+
+// A gotoTarget is a label jumped to by goto statements. The statements
+// from the label onwards run when the state of its goto block is index.
+type gotoTarget struct {
+	loop  *loop
+	state *py.Name
+	index int
+}
+
+func (c *Compiler) pushLoop(stmt ast.Stmt) *loop {
+	l := &loop{stmt: stmt}
+	c.loops = append(c.loops, l)
+	return l
+}
+
+// popLoop ends the innermost loop and returns the statements that follow it
+// to complete branches out of it.
+func (c *Compiler) popLoop() []py.Stmt {
+	l := c.loops[len(c.loops)-1]
+	c.loops = c.loops[:len(c.loops)-1]
+	var stmts []py.Stmt
+	for _, exit := range l.exits {
+		outer := c.loops[len(c.loops)-1]
+		body := outer.breakStmts()
+		if exit.cont && exit.target == outer {
+			body = []py.Stmt{&py.Assign{Targets: []py.Expr{exit.flag}, Value: pyFalse}}
+			body = append(body, exit.target.continueStmts()...)
+		}
+		stmts = append(stmts, &py.If{Test: exit.flag, Body: body})
+	}
+	return stmts
+}
+
+// initFlags initializes the flags of l before the loop starts.
+func (l *loop) initFlags() []py.Stmt {
+	var stmts []py.Stmt
+	for _, flag := range []*py.Name{l.breakFlag, l.continueFlag} {
+		if flag != nil {
+			stmts = append(stmts, &py.Assign{Targets: []py.Expr{flag}, Value: pyFalse})
+		}
+	}
+	return stmts
+}
+
+// breakStmts returns the statements that break l from its body.
+func (l *loop) breakStmts() []py.Stmt {
+	if l.isFunc {
+		return []py.Stmt{&py.Return{Value: pyFalse}}
+	}
+	return []py.Stmt{&py.Break{}}
+}
+
+// continueStmts returns the statements that continue l from its body: the
+// post statement of a for loop, then a Python continue.
+func (l *loop) continueStmts() []py.Stmt {
+	if l.isFunc {
+		return []py.Stmt{&py.Return{Value: pyTrue}}
+	}
+	stmts := append([]py.Stmt{}, l.post...)
+	return append(stmts, &py.Continue{})
+}
+
+// assigns records that the statement being compiled assigns name, a
+// variable of the function or loop body enclosing target, or of the function
+// if target is nil. If the statement is in the body of a range-over-func loop
+// inside target, the body must declare name nonlocal.
+func (c *Compiler) assigns(target *loop, name *py.Name) {
+	for i := len(c.loops) - 1; i >= 0 && c.loops[i] != target; i-- {
+		if l := c.loops[i]; l.isFunc {
+			if !l.assignsID(name.Id) {
+				l.nonlocals = append(l.nonlocals, name.Id)
+			}
+			return
+		}
+	}
+}
+
+func (l *loop) assignsID(id py.Identifier) bool {
+	for _, nonlocal := range l.nonlocals {
+		if nonlocal == id {
+			return true
+		}
+	}
+	return false
+}
+
+// flag returns the flag that nested loops set to break or continue l.
+func (c *Compiler) flag(l *loop, cont bool) *py.Name {
+	flag, name := &l.breakFlag, "break"
+	if cont {
+		flag, name = &l.continueFlag, "continue"
+	}
+	if label := []rune(c.label(l.stmt)); len(label) > 0 {
+		name += string(unicode.ToUpper(label[0])) + string(label[1:])
+	} else {
+		name += "Loop"
+	}
+	switch l.stmt.(type) {
+	case nil:
+		name = "jump"
+	case *ast.BlockStmt:
+		name = "returned"
+	}
+	if *flag == nil {
+		*flag = &py.Name{Id: c.tempID(name)}
+	}
+	return *flag
+}
+
+// label returns the name of the outermost label of stmt, or "" if it has
+// none.
+func (c *Compiler) label(stmt ast.Stmt) string {
+	var outermost types.Object
+	for label, labeled := range c.labeled {
+		if stmt != nil && labeled == stmt && (outermost == nil || label.Pos() < outermost.Pos()) {
+			outermost = label
+		}
+	}
+	if outermost == nil {
+		return ""
+	}
+	return outermost.Name()
+}
+
+// branch compiles a break of target, or a continue if cont is set.
+func (c *Compiler) branch(target *loop, cont bool) []py.Stmt {
+	innermost := c.loops[len(c.loops)-1]
+	if innermost == target {
+		if cont {
+			return target.continueStmts()
+		}
+		return target.breakStmts()
+	}
+	flag := c.flag(target, cont)
+	for i := len(c.loops) - 1; c.loops[i] != target; i-- {
+		l := c.loops[i]
+		if !l.exitsTo(flag) {
+			l.exits = append(l.exits, exit{flag: flag, target: target, cont: cont})
+		}
+	}
+	c.assigns(target, flag)
+	stmts := []py.Stmt{&py.Assign{Targets: []py.Expr{flag}, Value: pyTrue}}
+	return append(stmts, innermost.breakStmts()...)
+}
+
+func (l *loop) exitsTo(flag *py.Name) bool {
+	for _, exit := range l.exits {
+		if exit.flag == flag {
+			return true
+		}
+	}
+	return false
+}
+
+// branchTarget returns the loop that s breaks or continues.
+func (c *Compiler) branchTarget(s *ast.BranchStmt) *loop {
+	for i := len(c.loops) - 1; i >= 0; i-- {
+		l := c.loops[i]
+		if s.Label != nil {
+			if l.stmt != nil && l.stmt == c.labeled[c.Uses[s.Label]] {
+				return l
+			}
+			continue
+		}
+		switch l.stmt.(type) {
+		case *ast.ForStmt, *ast.RangeStmt:
+			return l
+		case nil, *ast.BlockStmt:
+			// Goto blocks and function bodies are not branch targets
+		default:
+			// Switch and select statements can be broken but not continued
+			if s.Tok == token.BREAK {
+				return l
+			}
+		}
+	}
+	panic(c.err(s, "%s is not in a loop, switch or select", s.Tok))
+}
+
+// collectLabels finds the labeled statements in body and the labels jumped
+// to by goto statements.
+func (c *Compiler) collectLabels(body *ast.BlockStmt) {
+	c.labeled = map[types.Object]ast.Stmt{}
+	c.gotos = map[types.Object]*gotoTarget{}
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch s := node.(type) {
+		case *ast.LabeledStmt:
+			stmt := s.Stmt
+			for labeled, ok := stmt.(*ast.LabeledStmt); ok; labeled, ok = stmt.(*ast.LabeledStmt) {
+				stmt = labeled.Stmt
+			}
+			c.labeled[c.Defs[s.Label]] = stmt
+		case *ast.BranchStmt:
+			if s.Tok == token.GOTO {
+				c.gotos[c.Uses[s.Label]] = nil
+			}
+		}
+		return true
+	})
+}
+
+// isBroken reports whether a break statement breaks stmt, a switch or select
+// statement.
+func (c *Compiler) isBroken(stmt ast.Stmt) bool {
+	broken := false
+	var inspect func(node ast.Node, nested bool)
+	inspect = func(node ast.Node, nested bool) {
+		ast.Inspect(node, func(n ast.Node) bool {
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.BranchStmt:
+				if n.Tok == token.BREAK {
+					if n.Label == nil && !nested || n.Label != nil && c.labeled[c.Uses[n.Label]] == stmt {
+						broken = true
+					}
+				}
+			case *ast.ForStmt, *ast.RangeStmt, *ast.SwitchStmt, *ast.TypeSwitchStmt, *ast.SelectStmt:
+				if n != node {
+					// Unlabeled breaks inside n break n
+					inspect(n, true)
+					return false
+				}
+			}
+			return true
+		})
+	}
+	inspect(stmt, false)
+	return broken
+}
+
+// compileBreakable compiles a switch or select statement. If a break
+// statement breaks it, it runs in a loop that runs once so the break can be
+// compiled to a Python break.
+func (c *Compiler) compileBreakable(stmt ast.Stmt, compile func() []py.Stmt) []py.Stmt {
+	if !c.isBroken(stmt) {
+		return compile()
+	}
+	l := c.pushLoop(stmt)
+	body := append(compile(), &py.Break{})
+	after := c.popLoop()
+	stmts := append(l.initFlags(), &py.While{Test: pyTrue, Body: body})
+	return append(stmts, after...)
+}
+
+func (c *Compiler) isGotoTarget(stmt ast.Stmt) bool {
+	labeled, ok := stmt.(*ast.LabeledStmt)
+	if !ok {
+		return false
+	}
+	_, ok = c.gotos[c.Defs[labeled.Label]]
+	return ok
+}
+
+// compileGotoBlock compiles a list of statements containing labels that goto
+// statements jump to. The list is split into segments at the labels, which
+// run in a loop so that a goto can set the segment to continue from:
+//
+//	label = 0
+//	while True:
+//	    if label <= 0:
+//	        ...
+//	    if label <= 1:
+//	        ...
+//	    break
+func (c *Compiler) compileGotoBlock(stmts []ast.Stmt) []py.Stmt {
+	state := &py.Name{Id: c.tempID("label")}
+	l := c.pushLoop(nil)
+	var segments [][]ast.Stmt
+	for _, stmt := range stmts {
+		for c.isGotoTarget(stmt) {
+			labeled := stmt.(*ast.LabeledStmt)
+			c.gotos[c.Defs[labeled.Label]] = &gotoTarget{loop: l, state: state, index: len(segments)}
+			segments = append(segments, nil)
+			stmt = labeled.Stmt
+		}
+		if len(segments) == 0 {
+			segments = append(segments, nil)
+		}
+		segments[len(segments)-1] = append(segments[len(segments)-1], stmt)
+	}
+	var body []py.Stmt
+	for i, segment := range segments {
+		compiled := c.compileStmts(segment)
+		if len(compiled) == 0 {
+			continue
+		}
+		test := &py.Compare{
+			Left:        state,
+			Ops:         []py.CmpOp{py.LtE},
+			Comparators: []py.Expr{&py.Num{N: strconv.Itoa(i)}},
+		}
+		body = append(body, &py.If{Test: test, Body: compiled})
+	}
+	body = append(body, &py.Break{})
+	after := c.popLoop()
+	pyStmts := append([]py.Stmt{&py.Assign{Targets: []py.Expr{state}, Value: &py.Num{N: "0"}}}, l.initFlags()...)
+	pyStmts = append(pyStmts, &py.While{Test: pyTrue, Body: body})
+	return append(pyStmts, after...)
+}
+
+// compileGoto compiles a goto statement, which sets the state of the goto
+// block containing the label and continues its loop.
+func (c *Compiler) compileGoto(s *ast.BranchStmt) []py.Stmt {
+	target := c.gotos[c.Uses[s.Label]]
+	if target == nil {
+		panic(c.err(s, "goto %s: label not in an enclosing block", s.Label.Name))
+	}
+	jump := &py.Assign{Targets: []py.Expr{target.state}, Value: &py.Num{N: strconv.Itoa(target.index)}}
+	c.assigns(target.loop, target.state)
+	return append([]py.Stmt{jump}, c.branch(target.loop, true)...)
+}
@@ -12,6 +12,23 @@ var (
 	pyEnumerate   = &py.Name{Id: py.Identifier("enumerate")}
 	pyType        = &py.Name{Id: py.Identifier("type")}
 	pyKeyError    = &py.Name{Id: py.Identifier("KeyError")}
+	pyException   = &py.Name{Id: py.Identifier("Exception")}
 	pyComplex     = &py.Name{Id: py.Identifier("complex")}
-	pyReversed    = &py.Name{Id: py.Identifier("reversed")}
+	pyOrd         = &py.Name{Id: py.Identifier("ord")}
+	pyHash        = &py.Name{Id: py.Identifier("hash")}
+	pyList        = &py.Name{Id: py.Identifier("list")}
+	pyBytes       = &py.Name{Id: py.Identifier("bytes")}
+	pyStr         = &py.Name{Id: py.Identifier("str")}
+	pyChr         = &py.Name{Id: py.Identifier("chr")}
 )
+
+// The typing module, imported by packages that declare interface types
+var typingModule = &py.Name{Id: py.Identifier("typing")}
+
+func typingAttr(name string) py.Expr {
+	return &py.Attribute{Value: typingModule, Attr: py.Identifier(name)}
+}
+
+func typingImport() py.Stmt {
+	return &py.Import{Names: []py.Alias{py.Alias{Name: typingModule.Id}}}
+}
@@ -19,18 +19,114 @@ type Module struct {
 	Types     []py.Stmt
 	Functions []*py.FunctionDef
 	Methods   map[py.Identifier][]*py.FunctionDef
+	Inits     []py.Identifier
+	Main      py.Identifier // "" if not a main package
 }
 
 type Compiler struct {
 	*types.Info
 	*scope
 	*token.FileSet
+
+	// GoVersion is the Go language version of the package, such as "go1.21",
+	// or "" for the latest version.
+	GoVersion string
+
 	commentMap *ast.CommentMap
 	defers     py.Expr
+	results    []types.Type              // result types of the function being compiled
+	addressed  map[types.Object]bool     // variables whose address is taken
+	captured   map[types.Object]bool     // per-iteration loop variables captured by function literals
+	loops      []*loop                   // Python loops enclosing the statement being compiled
+	labeled    map[types.Object]ast.Stmt // statements labeled in the function being compiled
+
+	// Named results of the function being compiled
+	namedResults    []*ast.Ident
+	deferredResults bool  // results are returned after running deferred calls
+	returnLoop      *loop // loop that return statements break, if any
+
+	// Set by return statements in range-over-func loop bodies
+	returned *py.Name // whether the function has returned
+	result   *py.Name // the results it returned
+
+	gotos map[types.Object]*gotoTarget // labels jumped to by goto in the function being compiled
+
+	// Function being compiled
+	funcType *ast.FuncType
+	funcBody *ast.BlockStmt
 }
 
 func NewCompiler(typeInfo *types.Info, fileSet *token.FileSet) *Compiler {
-	return &Compiler{Info: typeInfo, scope: newScope(), FileSet: fileSet}
+	return &Compiler{
+		Info:      typeInfo,
+		scope:     newScope(),
+		FileSet:   fileSet,
+		addressed: addressedVars(typeInfo),
+		captured:  map[types.Object]bool{},
+	}
+}
+
+// loopVarPerIteration reports whether each iteration of a loop has its own
+// copy of the variables the loop defines, as in Go 1.22 and later.
+func (c *Compiler) loopVarPerIteration() bool {
+	var major, minor int
+	if _, err := fmt.Sscanf(c.GoVersion, "go%d.%d", &major, &minor); err != nil {
+		return true
+	}
+	return major > 1 || minor >= 22
+}
+
+// addressedVars finds the variables whose address is taken, either by the &
+// operator or by calling a method with a pointer receiver.
+func addressedVars(info *types.Info) map[types.Object]bool {
+	addressed := map[types.Object]bool{}
+	addVar := func(expr ast.Expr) {
+		if ident, ok := unparen(expr).(*ast.Ident); ok {
+			if v, ok := info.ObjectOf(ident).(*types.Var); ok {
+				addressed[v] = true
+			}
+		}
+	}
+	for expr := range info.Types {
+		if unary, ok := expr.(*ast.UnaryExpr); ok && unary.Op == token.AND {
+			addVar(unary.X)
+		}
+	}
+	for sel, selection := range info.Selections {
+		if selection.Kind() != types.MethodVal {
+			continue
+		}
+		recv := selection.Obj().Type().(*types.Signature).Recv()
+		if _, ptr := recv.Type().(*types.Pointer); ptr && !selection.Indirect() {
+			if _, ptr := selection.Recv().(*types.Pointer); !ptr {
+				addVar(sel.X)
+			}
+		}
+	}
+	return addressed
+}
+
+// isBoxed reports whether obj is a variable stored in a runtime.Box so that
+// pointers to it can be made. Structs and arrays are Python objects already,
+// so they are never boxed.
+func (c *Compiler) isBoxed(obj types.Object) bool {
+	return c.addressed[obj] && !isValueType(obj.Type())
+}
+
+// boxDefs boxes the variables among idents that are defined by the
+// statement being compiled and whose address is taken.
+func (c *Compiler) boxDefs(idents ...*ast.Ident) []py.Stmt {
+	var stmts []py.Stmt
+	for _, ident := range idents {
+		if obj := c.Defs[ident]; obj != nil && c.isBoxed(obj) {
+			name := &py.Name{Id: c.objID(obj)}
+			stmts = append(stmts, &py.Assign{
+				Targets: []py.Expr{name},
+				Value:   runtimeCall("Box", name),
+			})
+		}
+	}
+	return stmts
 }
 
 func (c Compiler) nestedCompiler() *Compiler {
@@ -109,16 +205,123 @@ func (c *Compiler) addDefers(body *ast.BlockStmt) py.Stmt {
 	return nil
 }
 
+// modifies reports whether body may modify the struct or array variable obj,
+// which would otherwise share its value with the caller.
+func (c *Compiler) modifies(body *ast.BlockStmt, obj types.Object) bool {
+	isObj := func(expr ast.Expr) bool {
+		if ident, ok := unparen(expr).(*ast.Ident); ok && c.Defs[ident] != nil {
+			// Declaring obj does not modify it
+			return false
+		}
+		return c.rootIdent(expr) == obj
+	}
+	modified := false
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.AssignStmt:
+			for _, lhs := range n.Lhs {
+				modified = modified || isObj(lhs)
+			}
+		case *ast.IncDecStmt:
+			modified = modified || isObj(n.X)
+		case *ast.RangeStmt:
+			if n.Tok == token.ASSIGN {
+				modified = modified || (n.Key != nil && isObj(n.Key)) || (n.Value != nil && isObj(n.Value))
+			}
+		case *ast.UnaryExpr:
+			modified = modified || (n.Op == token.AND && isObj(n.X))
+		case *ast.SliceExpr:
+			modified = modified || isObj(n.X)
+		case *ast.SelectorExpr:
+			// A method with a pointer receiver takes the address of its receiver
+			if sel, ok := c.Selections[n]; ok && sel.Kind() == types.MethodVal {
+				recv := sel.Obj().Type().(*types.Signature).Recv()
+				if _, ptr := recv.Type().(*types.Pointer); ptr {
+					modified = modified || isObj(n.X)
+				}
+			}
+		}
+		return !modified
+	})
+	return modified
+}
+
+// rootIdent returns the variable whose value contains expr, which is a field
+// or element of a struct or array, or nil if there is none.
+func (c *Compiler) rootIdent(expr ast.Expr) types.Object {
+	switch e := expr.(type) {
+	case *ast.ParenExpr:
+		return c.rootIdent(e.X)
+	case *ast.Ident:
+		return c.ObjectOf(e)
+	case *ast.SelectorExpr:
+		if _, ok := c.TypeOf(e.X).Underlying().(*types.Struct); ok {
+			return c.rootIdent(e.X)
+		}
+	case *ast.IndexExpr:
+		if _, ok := c.TypeOf(e.X).Underlying().(*types.Array); ok {
+			return c.rootIdent(e.X)
+		}
+	}
+	return nil
+}
+
 func (parent *Compiler) compileFunc(name py.Identifier, typ *ast.FuncType, body *ast.BlockStmt, isMethod bool, recv *ast.Ident) *py.FunctionDef {
 	pyArgs := py.Arguments{}
 	// Compiler with nested function scope
 	c := parent.nestedCompiler()
+	c.declareFunc(recv, typ, body)
+	c.funcType, c.funcBody = typ, body
 
 	var pyBody []py.Stmt
 
 	// add an empty list of defer functions before the function body if this function uses defer
+	c.defers = nil
 	deferInit := c.addDefers(body)
 
+	c.loops = nil
+	c.returnLoop = nil
+	c.returned, c.result = nil, nil
+	c.collectLabels(body)
+	c.results = nil
+	c.namedResults = nil
+	if typ.Results != nil {
+		for _, field := range typ.Results.List {
+			t := c.TypeOf(field.Type)
+			c.results = append(c.results, t)
+			for i := 1; i < len(field.Names); i++ {
+				c.results = append(c.results, t)
+			}
+			for _, name := range field.Names {
+				c.namedResults = append(c.namedResults, name)
+			}
+		}
+	}
+	// Results are returned after the deferred calls, which may modify them
+	c.deferredResults = deferInit != nil && c.namedResults != nil
+
+	// Variables of the package and enclosing functions that the function
+	// assigns must be declared, or Python would make them local.
+	var globals, nonlocals []py.Identifier
+	for _, obj := range c.assignedVars(body) {
+		if c.isBoxed(obj) || c.captured[obj] {
+			// Assignments store the value in the box, or in the function's
+			// own copy of a loop variable
+			continue
+		}
+		if obj.Parent() == obj.Pkg().Scope() {
+			globals = append(globals, c.objID(obj))
+		} else if obj.Pos() < typ.Pos() || obj.Pos() >= body.End() {
+			nonlocals = append(nonlocals, c.objID(obj))
+		}
+	}
+	if globals != nil {
+		pyBody = append(pyBody, &py.Global{Names: globals})
+	}
+	if nonlocals != nil {
+		pyBody = append(pyBody, &py.Nonlocal{Names: nonlocals})
+	}
+
 	if isMethod {
 		var recvId py.Identifier
 		if recv != nil {
@@ -127,37 +330,92 @@ func (parent *Compiler) compileFunc(name py.Identifier, typ *ast.FuncType, body
 			recvId = c.tempID("self")
 		}
 		pyArgs.Args = append(pyArgs.Args, py.Arg{Arg: recvId})
+
+		// A value receiver is passed by reference, so copy it if the method
+		// modifies it.
+		if recv != nil {
+			obj := c.ObjectOf(recv)
+			if isValueType(obj.Type()) && c.modifies(body, obj) {
+				self := &py.Name{Id: recvId}
+				pyBody = append(pyBody, &py.Assign{
+					Targets: []py.Expr{self},
+					Value:   c.copyValue(self, obj.Type()),
+				})
+			}
+			pyBody = append(pyBody, c.boxDefs(recv)...)
+		}
 	}
 	for _, param := range typ.Params.List {
 		for _, name := range param.Names {
 			pyArgs.Args = append(pyArgs.Args, py.Arg{Arg: c.identifier(name)})
+			pyBody = append(pyBody, c.boxDefs(name)...)
+		}
+	}
+
+	// Loop variables are bound when the function is created, because each
+	// iteration has its own variables
+	for _, obj := range c.capturedVars(typ, body) {
+		id := c.objID(obj)
+		pyArgs.Kwonlyargs = append(pyArgs.Kwonlyargs, py.Arg{Arg: id})
+		pyArgs.KwDefaults = append(pyArgs.KwDefaults, &py.Name{Id: id})
+	}
+
+	// Named results start as zero values
+	if c.namedResults != nil {
+		var targets, values []py.Expr
+		for _, ident := range c.namedResults {
+			targets = append(targets, &py.Name{Id: c.identifier(ident)})
+			values = append(values, c.boxValue(ident, c.zeroValue(c.TypeOf(ident))))
 		}
+		pyBody = append(pyBody, &py.Assign{Targets: targets, Value: makeTuple(values...)})
 	}
 
-	for _, stmt := range body.List {
-		pyBody = append(pyBody, c.compileStmt(stmt)...)
+	if c.deferredResults && c.returnsEarly(body) {
+		// Run the body in a loop that a return statement can break out of
+		c.returnLoop = c.pushLoop(body)
+		stmts := c.compileStmts(body.List)
+		if _, ok := stmts[len(stmts)-1].(*py.Break); !ok {
+			stmts = append(stmts, &py.Break{})
+		}
+		c.popLoop()
+		pyBody = append(pyBody, c.returnLoop.initFlags()...)
+		pyBody = append(pyBody, &py.While{Test: pyTrue, Body: stmts})
+	} else {
+		pyBody = append(pyBody, c.compileStmts(body.List)...)
 	}
 
-	// Execute defers
+	// Execute defers. If the body panics, the deferred calls run with the
+	// panic in progress and, if one of them recovers, the function returns
+	// its named results or the zero values of its results.
 	if deferInit != nil {
-		fun := &py.Name{Id: c.tempID("fun")}
-		args := &py.Name{Id: c.tempID("args")}
-		forLoop := &py.For{
-			Target: makeTuple(fun, args),
-			Iter:   &py.Call{Func: pyReversed, Args: []py.Expr{c.defers}},
-			Body: []py.Stmt{
-				&py.ExprStmt{
-					Value: &py.Call{Func: fun, Args: []py.Expr{&py.Starred{Value: args}}},
-				},
-			},
+		exc := c.tempID("exc")
+		runDefers := &py.ExprStmt{Value: runtimeCall("runDefers", c.defers)}
+		unwind := &py.ExprStmt{Value: runtimeCall("runDefers", c.defers, &py.Name{Id: exc})}
+		handler := []py.Stmt{unwind}
+		if len(c.results) > 0 && !c.deferredResults {
+			var results []py.Expr
+			for _, t := range c.results {
+				results = append(results, c.zeroValue(t))
+			}
+			handler = append(handler, &py.Return{Value: makeTuple(results...)})
 		}
 		pyBody = []py.Stmt{
 			deferInit,
 			&py.Try{
-				Body:      pyBody,
-				Finalbody: []py.Stmt{forLoop},
+				Body: pyBody,
+				Handlers: []py.ExceptHandler{
+					py.ExceptHandler{
+						Typ:  pyException,
+						Name: exc,
+						Body: handler,
+					},
+				},
+				Finalbody: []py.Stmt{runDefers},
 			},
 		}
+		if c.deferredResults {
+			pyBody = append(pyBody, &py.Return{Value: c.namedResultValues()})
+		}
 	}
 
 	if len(pyBody) == 0 {
@@ -166,6 +424,100 @@ func (parent *Compiler) compileFunc(name py.Identifier, typ *ast.FuncType, body
 	return &py.FunctionDef{Name: name, Args: pyArgs, Body: pyBody}
 }
 
+// namedResult returns the value of a named result of the function being
+// compiled, which is also the target of assignments to it.
+func (c *Compiler) namedResult(ident *ast.Ident) py.Expr {
+	obj := c.Defs[ident]
+	name := &py.Name{Id: c.objID(obj)}
+	if c.isBoxed(obj) {
+		return &py.Attribute{Value: name, Attr: py.Identifier("value")}
+	}
+	return name
+}
+
+// namedResultValues returns the values of the named results of the function
+// being compiled.
+func (c *Compiler) namedResultValues() py.Expr {
+	var values []py.Expr
+	for _, ident := range c.namedResults {
+		values = append(values, c.namedResult(ident))
+	}
+	return makeTuple(values...)
+}
+
+// returnsEarly reports whether body has a return statement other than its
+// last statement.
+func (c *Compiler) returnsEarly(body *ast.BlockStmt) bool {
+	early := false
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch node := node.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.ReturnStmt:
+			if node != body.List[len(body.List)-1] {
+				early = true
+			}
+		}
+		return true
+	})
+	return early
+}
+
+// capturedVars returns the per-iteration loop variables of enclosing
+// functions that a function refers to.
+func (c *Compiler) capturedVars(typ *ast.FuncType, body *ast.BlockStmt) []types.Object {
+	var vars []types.Object
+	seen := map[types.Object]bool{}
+	ast.Inspect(body, func(node ast.Node) bool {
+		if ident, ok := node.(*ast.Ident); ok {
+			obj := c.Uses[ident]
+			if c.captured[obj] && !seen[obj] && (obj.Pos() < typ.Pos() || obj.Pos() >= body.End()) {
+				seen[obj] = true
+				vars = append(vars, obj)
+			}
+		}
+		return true
+	})
+	return vars
+}
+
+// assignedVars returns the variables assigned by statements in body, not
+// counting those in function literals or defined by the assignment.
+func (c *Compiler) assignedVars(body *ast.BlockStmt) []types.Object {
+	var vars []types.Object
+	seen := map[types.Object]bool{}
+	add := func(expr ast.Expr) {
+		if ident, ok := unparen(expr).(*ast.Ident); ok {
+			if obj, ok := c.Uses[ident].(*types.Var); ok && !seen[obj] {
+				seen[obj] = true
+				vars = append(vars, obj)
+			}
+		}
+	}
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch s := node.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.AssignStmt:
+			for _, lhs := range s.Lhs {
+				add(lhs)
+			}
+		case *ast.IncDecStmt:
+			add(s.X)
+		case *ast.RangeStmt:
+			if s.Tok == token.ASSIGN {
+				for _, x := range []ast.Expr{s.Key, s.Value} {
+					if x != nil {
+						add(x)
+					}
+				}
+			}
+		}
+		return true
+	})
+	return vars
+}
+
 func makeDocString(g *ast.CommentGroup) *py.DocString {
 	text := g.Text()
 	text = strings.TrimRight(text, "\n")
@@ -185,7 +537,11 @@ func (c *Compiler) compileFuncDecl(decl *ast.FuncDecl) FuncDecl {
 		}
 		recvType = c.fieldType(field)
 	}
-	funcDef := c.compileFunc(c.identifier(decl.Name), decl.Type, decl.Body, decl.Recv != nil, recv)
+	name := c.identifier(decl.Name)
+	if decl.Recv != nil {
+		name = fieldID(c.ObjectOf(decl.Name))
+	}
+	funcDef := c.compileFunc(name, decl.Type, decl.Body, decl.Recv != nil, recv)
 
 	if decl.Doc != nil {
 		funcDef.Body = append([]py.Stmt{makeDocString(decl.Doc)}, funcDef.Body...)
@@ -195,8 +551,10 @@ func (c *Compiler) compileFuncDecl(decl *ast.FuncDecl) FuncDecl {
 
 func (c *Compiler) zeroValue(typ types.Type) py.Expr {
 	switch t := typ.(type) {
-	case *types.Pointer, *types.Slice, *types.Map, *types.Signature, *types.Interface, *types.Chan:
+	case *types.Pointer, *types.Map, *types.Signature, *types.Interface, *types.Chan:
 		return pyNone
+	case *types.Slice:
+		return runtimeAttr("nilSlice")
 	case *types.Basic:
 		switch {
 		case t.Info()&types.IsString != 0:
@@ -211,7 +569,16 @@ func (c *Compiler) zeroValue(typ types.Type) py.Expr {
 			panic(fmt.Sprintf("unknown basic type %#v", t))
 		}
 	case *types.Named:
-		return &py.Call{Func: &py.Name{Id: py.Identifier(t.Obj().Name())}}
+		if _, ok := t.Underlying().(*types.Struct); !ok {
+			return c.zeroValue(t.Underlying())
+		}
+		return &py.Call{Func: &py.Name{Id: c.objID(t.Obj())}}
+	case *types.Struct:
+		if t.NumFields() == 0 {
+			// struct{} has no state, so its values are None
+			return pyNone
+		}
+		panic(fmt.Sprintf("zero value of unnamed struct type %s", t))
 	case *types.Array:
 		return &py.ListComp{
 			Elt: c.zeroValue(t.Elem()),
@@ -230,29 +597,86 @@ func (c *Compiler) zeroValue(typ types.Type) py.Expr {
 	}
 }
 
+// initArgs returns the names of the parameters of the __init__ method of a
+// struct class. They are the names of the fields, unless the zero value of
+// a field refers to that name, which the parameter would hide.
+func (c *Compiler) initArgs(typ *types.Struct) []py.Identifier {
+	used := map[py.Identifier]bool{}
+	for i := 0; i < typ.NumFields(); i++ {
+		if t := typ.Field(i).Type(); isValueType(t) {
+			usedNames(c.zeroValue(t), used)
+		}
+	}
+	var ids []py.Identifier
+	for i := 0; i < typ.NumFields(); i++ {
+		id := fieldID(typ.Field(i))
+		for used[id] {
+			id += "_"
+		}
+		used[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// usedNames adds the names that zero, a zero value, refers to to used.
+func usedNames(zero py.Expr, used map[py.Identifier]bool) {
+	switch e := zero.(type) {
+	case *py.Name:
+		used[e.Id] = true
+	case *py.Attribute:
+		usedNames(e.Value, used)
+	case *py.Call:
+		usedNames(e.Func, used)
+		for _, arg := range e.Args {
+			usedNames(arg, used)
+		}
+	case *py.ListComp:
+		usedNames(e.Elt, used)
+		for _, gen := range e.Generators {
+			usedNames(gen.Iter, used)
+		}
+	}
+}
+
 func (c *Compiler) makeInitMethod(typ *types.Struct) *py.FunctionDef {
 	nested := c.nestedCompiler()
 	args := []py.Arg{py.Arg{Arg: pySelf}}
 	var defaults []py.Expr
+	var body []py.Stmt
+	ids := c.initArgs(typ)
 	for i := 0; i < typ.NumFields(); i++ {
 		field := typ.Field(i)
-		arg := py.Arg{Arg: nested.objID(field)}
+		id := ids[i]
+		arg := py.Arg{Arg: id}
 		args = append(args, arg)
 		dflt := nested.zeroValue(field.Type())
+		if isValueType(field.Type()) {
+			// Default argument values are evaluated once, so a struct or
+			// array default would be shared by every instance.
+			body = append(body, &py.If{
+				Test: &py.Compare{
+					Left:        &py.Name{Id: id},
+					Ops:         []py.CmpOp{py.Is},
+					Comparators: []py.Expr{pyNone},
+				},
+				Body: []py.Stmt{&py.Assign{Targets: []py.Expr{&py.Name{Id: id}}, Value: dflt}},
+			})
+			dflt = pyNone
+		}
 		defaults = append(defaults, dflt)
 	}
 
-	var body []py.Stmt
 	for i := 0; i < typ.NumFields(); i++ {
 		field := typ.Field(i)
 		assign := &py.Assign{
 			Targets: []py.Expr{
 				&py.Attribute{
 					Value: &py.Name{Id: pySelf},
-					Attr:  nested.objID(field),
+					Attr:  fieldID(field),
 				},
 			},
-			Value: &py.Name{Id: nested.objID(field)},
+			Value: &py.Name{Id: ids[i]},
 		}
 		body = append(body, assign)
 	}
@@ -264,52 +688,222 @@ func (c *Compiler) makeInitMethod(typ *types.Struct) *py.FunctionDef {
 	return initMethod
 }
 
-func (c *Compiler) compileStructType(ident *ast.Ident, typ *types.Struct) *py.ClassDef {
-	var body []py.Stmt
+// makeCopyMethod makes a __copy__ method that copies a struct value, as Go
+// does when a struct is assigned, passed or returned.
+func (c *Compiler) makeCopyMethod(class py.Identifier, typ *types.Struct) *py.FunctionDef {
+	self := &py.Name{Id: pySelf}
+	var fields []py.Expr
+	for i := 0; i < typ.NumFields(); i++ {
+		field := typ.Field(i)
+		value := &py.Attribute{Value: self, Attr: fieldID(field)}
+		fields = append(fields, c.copyValue(value, field.Type()))
+	}
+	return &py.FunctionDef{
+		Name: py.Identifier("__copy__"),
+		Args: py.Arguments{Args: []py.Arg{{Arg: pySelf}}},
+		Body: []py.Stmt{&py.Return{Value: &py.Call{Func: &py.Name{Id: class}, Args: fields}}},
+	}
+}
 
-	if c.commentMap != nil {
-		doc := (*c.commentMap)[ident]
-		if len(doc) > 0 {
-			body = append(body, makeDocString(doc[0]))
+// makeEqMethods makes __eq__ and __hash__ methods that compare struct values
+// field by field, so that structs can be compared and used as map keys.
+func (c *Compiler) makeEqMethods(typ *types.Struct) []py.Stmt {
+	self := &py.Name{Id: pySelf}
+	other := &py.Name{Id: py.Identifier("other")}
+	var eqs []py.Expr
+	var keys []py.Expr
+	for i := 0; i < typ.NumFields(); i++ {
+		field := typ.Field(i)
+		id := fieldID(field)
+		eqs = append(eqs, &py.Compare{
+			Left:        &py.Attribute{Value: self, Attr: id},
+			Ops:         []py.CmpOp{py.Eq},
+			Comparators: []py.Expr{&py.Attribute{Value: other, Attr: id}},
+		})
+		var key py.Expr = &py.Attribute{Value: self, Attr: id}
+		if _, ok := field.Type().Underlying().(*types.Array); ok {
+			// Lists are not hashable
+			key = runtimeCall("arrayKey", key)
 		}
+		keys = append(keys, key)
+	}
+	var eq py.Expr
+	switch len(eqs) {
+	case 0:
+		eq = pyTrue
+	case 1:
+		eq = eqs[0]
+	default:
+		eq = &py.BoolOpExpr{Op: py.And, Values: eqs}
+	}
+	selfArgs := py.Arguments{Args: []py.Arg{{Arg: pySelf}}}
+	return []py.Stmt{
+		&py.FunctionDef{
+			Name: py.Identifier("__eq__"),
+			Args: py.Arguments{Args: []py.Arg{{Arg: pySelf}, {Arg: other.Id}}},
+			Body: []py.Stmt{&py.Return{Value: eq}},
+		},
+		&py.FunctionDef{
+			Name: py.Identifier("__hash__"),
+			Args: selfArgs,
+			Body: []py.Stmt{&py.Return{Value: &py.Call{
+				Func: pyHash,
+				Args: []py.Expr{&py.Tuple{Elts: keys}},
+			}}},
+		},
+	}
+}
+
+func (c *Compiler) compileStructType(ident *ast.Ident, typ *types.Struct, doc *ast.CommentGroup) *py.ClassDef {
+	var body []py.Stmt
+
+	if doc != nil {
+		body = append(body, makeDocString(doc))
+	}
+	if pointerMethods := c.pointerMethods(ident); pointerMethods != nil {
+		body = append(body, pointerMethods)
 	}
 
 	if typ.NumFields() > 0 {
 		body = append(body, c.makeInitMethod(typ))
 	}
+	name := c.identifier(ident)
+	body = append(body, c.makeCopyMethod(name, typ))
+	if types.Comparable(typ) {
+		body = append(body, c.makeEqMethods(typ)...)
+	}
+
+	return &py.ClassDef{
+		Name:          name,
+		Bases:         nil,
+		Keywords:      nil,
+		Body:          body,
+		DecoratorList: nil,
+	}
+}
+
+// compileInterfaceType compiles an interface type to a protocol class
+// listing its method set, including the methods of embedded interfaces.
+func (c *Compiler) compileInterfaceType(ident *ast.Ident, expr *ast.InterfaceType, typ *types.Interface, doc *ast.CommentGroup) *py.ClassDef {
+	var body []py.Stmt
+
+	if doc != nil {
+		body = append(body, makeDocString(doc))
+	}
+
+	// Doc comments of the methods declared by this interface
+	docs := map[string]*ast.CommentGroup{}
+	if expr != nil {
+		for _, field := range expr.Methods.List {
+			for _, name := range field.Names {
+				docs[name.Name] = field.Doc
+			}
+		}
+	}
+
+	for i := 0; i < typ.NumMethods(); i++ {
+		method := typ.Method(i)
+		nested := c.nestedCompiler()
+		args := []py.Arg{{Arg: pySelf}}
+		params := method.Type().(*types.Signature).Params()
+		for j := 0; j < params.Len(); j++ {
+			param := params.At(j)
+			var id py.Identifier
+			if param.Name() == "" || param.Name() == "_" {
+				id = nested.tempID("arg")
+			} else {
+				id = nested.objID(param)
+			}
+			args = append(args, py.Arg{Arg: id})
+		}
+		var methodBody []py.Stmt
+		if doc := docs[method.Name()]; doc != nil {
+			methodBody = append(methodBody, makeDocString(doc))
+		}
+		methodBody = append(methodBody, &py.ExprStmt{Value: &py.Ellipsis{}})
+		body = append(body, &py.FunctionDef{
+			Name: fieldID(method),
+			Args: py.Arguments{Args: args},
+			Body: methodBody,
+		})
+	}
 
 	if len(body) == 0 {
 		body = []py.Stmt{&py.Pass{}}
 	}
 	return &py.ClassDef{
 		Name:          c.identifier(ident),
-		Bases:         nil,
-		Keywords:      nil,
+		Bases:         []py.Expr{typingAttr("Protocol")},
 		Body:          body,
-		DecoratorList: nil,
+		DecoratorList: []py.Expr{typingAttr("runtime_checkable")},
 	}
 }
 
-func (c *Compiler) compileInterfaceType(ident *ast.Ident, typ *types.Interface) py.Stmt {
-	return nil
+// typeDoc returns the doc comment of spec, which is part of decl.
+func typeDoc(decl *ast.GenDecl, spec *ast.TypeSpec) *ast.CommentGroup {
+	if spec.Doc == nil && !decl.Lparen.IsValid() {
+		// type T struct{...}
+		return decl.Doc
+	}
+	return spec.Doc
 }
 
-func (c *Compiler) compileTypeSpec(spec *ast.TypeSpec) py.Stmt {
+func (c *Compiler) compileTypeSpec(decl *ast.GenDecl, spec *ast.TypeSpec) py.Stmt {
+	doc := typeDoc(decl, spec)
 	switch t := c.TypeOf(spec.Type).(type) {
 	case *types.Struct:
-		return c.compileStructType(spec.Name, t)
+		return c.compileStructType(spec.Name, t, doc)
 	case *types.Named:
 		return &py.Assign{
 			Targets: []py.Expr{&py.Name{Id: c.identifier(spec.Name)}},
 			Value:   &py.Name{Id: c.objID(t.Obj())},
 		}
 	case *types.Interface:
-		return c.compileInterfaceType(spec.Name, t)
-	case *types.Basic, *types.Slice:
-		fields := []*types.Var{types.NewField(token.NoPos, nil, "value", t, false)}
-		return c.compileStructType(spec.Name, types.NewStruct(fields, nil))
+		expr, _ := spec.Type.(*ast.InterfaceType)
+		return c.compileInterfaceType(spec.Name, expr, t, doc)
 	default:
-		panic(c.err(spec, "unknown TypeSpec: %T", t))
+		return c.compileNamedType(spec.Name, doc)
+	}
+}
+
+// compileNamedType compiles a named type whose underlying type is not a
+// struct or interface. Its values have the Python representation of the
+// underlying type, and the class holds its methods and describes the type.
+func (c *Compiler) compileNamedType(ident *ast.Ident, doc *ast.CommentGroup) *py.ClassDef {
+	var body []py.Stmt
+	if doc != nil {
+		body = append(body, makeDocString(doc))
+	}
+	if pointerMethods := c.pointerMethods(ident); pointerMethods != nil {
+		body = append(body, pointerMethods)
+	}
+	if len(body) == 0 {
+		body = []py.Stmt{&py.Pass{}}
+	}
+	return &py.ClassDef{Name: c.identifier(ident), Body: body}
+}
+
+// pointerMethods lists the methods of the named type ident that have pointer
+// receivers, which are not in the method set of the type itself. It returns
+// nil if there are none.
+func (c *Compiler) pointerMethods(ident *ast.Ident) py.Stmt {
+	named, ok := c.ObjectOf(ident).Type().(*types.Named)
+	if !ok {
+		return nil
+	}
+	var names []py.Expr
+	for i := 0; i < named.NumMethods(); i++ {
+		method := named.Method(i)
+		if _, ok := method.Type().(*types.Signature).Recv().Type().(*types.Pointer); ok {
+			names = append(names, &py.Str{S: fmt.Sprintf("%q", fieldID(method))})
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	return &py.Assign{
+		Targets: []py.Expr{&py.Name{Id: py.Identifier("_pointerMethods")}},
+		Value:   &py.Tuple{Elts: names},
 	}
 }
 
@@ -321,7 +915,7 @@ func (c *Compiler) compileGenDecl(decl *ast.GenDecl, module *Module) {
 	for _, spec := range decl.Specs {
 		switch s := spec.(type) {
 		case *ast.TypeSpec:
-			compiled := c.compileTypeSpec(s)
+			compiled := c.compileTypeSpec(decl, s)
 			if classDef, ok := compiled.(*py.ClassDef); ok {
 				module.Classes = append(module.Classes, classDef)
 			} else {
@@ -330,6 +924,10 @@ func (c *Compiler) compileGenDecl(decl *ast.GenDecl, module *Module) {
 		case *ast.ImportSpec:
 			c.compileImportSpec(s, module)
 		case *ast.ValueSpec:
+			if decl.Tok == token.VAR && len(s.Values) > 0 {
+				// Initialized in dependency order by compileInitOrder
+				continue
+			}
 			module.Values = append(module.Values, c.compileValueSpec(s)...)
 		default:
 			c.err(s, "unknown Spec: %T", s)
@@ -341,6 +939,12 @@ func (c *Compiler) compileDecl(decl ast.Decl, module *Module) {
 	switch d := decl.(type) {
 	case *ast.FuncDecl:
 		funcDecl := c.compileFuncDecl(d)
+		if d.Recv == nil && d.Name.Name == "init" {
+			module.Inits = append(module.Inits, funcDecl.Def.Name)
+		}
+		if d.Recv == nil && d.Name.Name == "main" && c.ObjectOf(d.Name).Pkg().Name() == "main" {
+			module.Main = funcDecl.Def.Name
+		}
 		if funcDecl.Class != py.Identifier("") {
 			module.Methods[funcDecl.Class] = append(module.Methods[funcDecl.Class], funcDecl.Def)
 		} else {
@@ -362,21 +966,95 @@ func (c *Compiler) compileFile(file *ast.File, module *Module) {
 	}
 }
 
+// declaresInterfaces reports whether the package declares any named
+// interface types.
+func (c *Compiler) declaresInterfaces() bool {
+	for _, obj := range c.Defs {
+		if typeName, ok := obj.(*types.TypeName); ok {
+			if named, ok := typeName.Type().(*types.Named); ok && !typeName.IsAlias() {
+				if _, ok := named.Underlying().(*types.Interface); ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// compileInitOrder initializes package-level variables in the order
+// required by the Go spec, which may differ from the order of declaration.
+func (c *Compiler) compileInitOrder() []py.Stmt {
+	var stmts []py.Stmt
+	for _, init := range c.InitOrder {
+		e := c.exprCompiler()
+		var targets []py.Expr
+		var boxes []py.Stmt
+		for _, v := range init.Lhs {
+			name := &py.Name{Id: c.objID(v)}
+			targets = append(targets, name)
+			if c.isBoxed(v) {
+				boxes = append(boxes, &py.Assign{Targets: []py.Expr{name}, Value: runtimeCall("Box", name)})
+			}
+		}
+		var value py.Expr
+		if len(init.Lhs) == 1 {
+			value = e.compileValue(init.Rhs, init.Lhs[0].Type())
+		} else if len(init.Lhs) == 2 {
+			// var v, ok = m[k]
+			value = e.compileCommaOk(init.Rhs)
+		}
+		if value == nil {
+			value = e.compileExpr(init.Rhs)
+		}
+		stmts = append(stmts, e.stmts...)
+		stmts = append(stmts, &py.Assign{Targets: targets, Value: value})
+		stmts = append(stmts, boxes...)
+	}
+	return stmts
+}
+
+// CompileFiles compiles the files of a package into a Python module.
+//
+// Classes and functions are defined before package-level variables are
+// initialized because the initializers may refer to them.
 func (c *Compiler) CompileFiles(files []*ast.File) *py.Module {
-	module := &Module{Methods: map[py.Identifier][]*py.FunctionDef{}}
+	module := c.newModule()
+	module.Imports = runtimeImport()
+	if c.declaresInterfaces() {
+		// Interface types are compiled to typing.Protocol classes
+		module.Imports = append([]py.Stmt{typingImport()}, module.Imports...)
+	}
 	for _, file := range files {
 		c.compileFile(file, module)
 	}
+	module.Values = append(module.Values, c.compileInitOrder()...)
+
 	pyModule := &py.Module{}
-	pyModule.Body = append(pyModule.Body, module.Values...)
+	pyModule.Body = append(pyModule.Body, module.Imports...)
 	for _, class := range module.Classes {
 		for _, method := range module.Methods[class.Name] {
 			class.Body = append(class.Body, method)
 		}
 		pyModule.Body = append(pyModule.Body, class)
 	}
+	pyModule.Body = append(pyModule.Body, module.Types...)
 	for _, fun := range module.Functions {
 		pyModule.Body = append(pyModule.Body, fun)
 	}
+	pyModule.Body = append(pyModule.Body, module.Values...)
+	for _, init := range module.Inits {
+		pyModule.Body = append(pyModule.Body, &py.ExprStmt{Value: &py.Call{Func: &py.Name{Id: init}}})
+	}
+	if module.Main != "" {
+		// The program exits when main returns, even if other goroutines are still running.
+		pyModule.Body = append(pyModule.Body, &py.If{
+			Test: &py.Compare{
+				Left:        &py.Name{Id: py.Identifier("__name__")},
+				Ops:         []py.CmpOp{py.Eq},
+				Comparators: []py.Expr{&py.Str{S: `"__main__"`}},
+			},
+			Body: []py.Stmt{&py.ExprStmt{Value: runtimeCall("main", &py.Name{Id: module.Main})}},
+		})
+	}
 	return pyModule
 }
@@ -0,0 +1,196 @@
+package compiler
+
+import (
+	"bytes"
+	py "github.com/mbergin/gotopython/pythonast"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+var moduleTests = []struct {
+	golang string
+	python string
+}{
+	{"package main", `import runtime
+runtime.checkVersion(1)
+`},
+	// Package-level variables are initialized in dependency order after
+	// classes and functions are defined.
+	{`package main
+var a = b + 1
+var b = f()
+var c int
+type T struct{}
+func f() int { return 0 }
+func init() {}
+func init() {}
+`, `import runtime
+runtime.checkVersion(1)
+
+class T:
+    
+    def __copy__(self):
+        return T()
+    
+    def __eq__(self, other):
+        return True
+    
+    def __hash__(self):
+        return hash(())
+
+def f():
+    return 0
+
+def init():
+    pass
+
+def init1():
+    pass
+c = 0
+b = f()
+a = runtime.int((b + 1))
+init()
+init1()
+`},
+	// Package-level comma-ok specs yield the value and whether it was found.
+	{`package main
+var m map[string]int
+var v, ok = m["k"]
+`, `import runtime
+runtime.checkVersion(1)
+m = None
+v, ok = runtime.mapIndexOk(m, "k", 0)
+`},
+	// Packages that declare interface types import typing.
+	{"package main; type I interface{}", `import typing
+import runtime
+runtime.checkVersion(1)
+
+@typing.runtime_checkable
+class I(typing.Protocol):
+    pass
+`},
+	// A main package runs main when executed as a script.
+	{"package main; func main() {}", `import runtime
+runtime.checkVersion(1)
+
+def main():
+    pass
+if __name__ == "__main__":
+    runtime.main(main)
+`},
+	{"package lib; func main() {}", `import runtime
+runtime.checkVersion(1)
+
+def main():
+    pass
+`},
+	// Constants are declared with their values, which depend on iota in
+	// implicitly repeated specs.
+	{`package main
+type Color int
+const (
+	Red Color = iota
+	Green
+	Blue = Green << 62
+)
+`, `import runtime
+runtime.checkVersion(1)
+
+class Color:
+    pass
+Red = 0
+Green = 1
+Blue = 4611686018427387904
+`},
+	// Interface methods are named like the methods that implement them.
+	{`package lib
+type I interface{ from() }
+type T struct{}
+func (*T) from() {}
+func f(x interface{}) { _, _ = x.(interface{ from() }) }
+`, `import typing
+import runtime
+runtime.checkVersion(1)
+
+@typing.runtime_checkable
+class I(typing.Protocol):
+    
+    def from_(self):
+        ...
+
+class T:
+    _pointerMethods = "from_",
+    
+    def __copy__(self):
+        return T()
+    
+    def __eq__(self, other):
+        return True
+    
+    def __hash__(self):
+        return hash(())
+    
+    def from_(self):
+        pass
+
+def f(x):
+    _, _ = runtime.typeAssertOk(x, runtime.interfaceType("from_"))
+`},
+	// The switch tag temporary must not hide a local of the same name.
+	{`package main
+func f(tag int) int {
+	switch tag + 1 {
+	case 2:
+		return tag
+	}
+	return 0
+}
+`, `import runtime
+runtime.checkVersion(1)
+
+def f(tag):
+    tag1 = runtime.int((tag + 1))
+    if tag1 == 2:
+        return tag
+    return 0
+`},
+	// Go identifiers must not shadow the runtime module.
+	{"package main; var runtime = 1", `import runtime
+runtime.checkVersion(1)
+runtime1 = 1
+`},
+}
+
+func TestCompileFiles(t *testing.T) {
+	for _, test := range moduleTests {
+		t.Run(test.golang, func(t *testing.T) {
+			pkg, _, errs := buildFile(test.golang)
+			if errs != nil {
+				t.Errorf("failed to build Go package %q", test.golang)
+				for _, e := range errs {
+					t.Error(e)
+				}
+				t.FailNow()
+			}
+
+			c := NewCompiler(&pkg.Info, nil)
+			var buf bytes.Buffer
+			py.NewWriter(&buf).WriteModule(c.CompileFiles(pkg.Files))
+			if got := buf.String(); got != test.python {
+				t.Errorf("%q\nwant:\n%s\ngot:\n%s\n", test.golang, test.python, got)
+			}
+		})
+	}
+}
+
+func TestRuntimeVersion(t *testing.T) {
+	match := regexp.MustCompile(`(?m)^VERSION = (\d+)$`).FindStringSubmatch(RuntimeSource)
+	if match == nil {
+		t.Fatal("runtime.py does not declare VERSION")
+	}
+	if version, _ := strconv.Atoi(match[1]); version != RuntimeVersion {
+		t.Errorf("runtime.py has VERSION = %d but RuntimeVersion = %d", version, RuntimeVersion)
+	}
+}
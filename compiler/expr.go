@@ -4,9 +4,12 @@ import (
 	"fmt"
 	py "github.com/mbergin/gotopython/pythonast"
 	"go/ast"
+	"go/constant"
 	"go/token"
 	"go/types"
+	"strconv"
 	"strings"
+	"unicode/utf8"
 )
 
 type exprCompiler struct {
@@ -29,8 +32,14 @@ func (c *Compiler) compileIdent(ident *ast.Ident) py.Expr {
 		return pyFalse
 	case builtin.nil:
 		return pyNone
+	case builtin.panic, builtin.print, builtin.println, builtin.recover:
+		return runtimeAttr(obj.Name())
 	default:
-		return &py.Name{Id: c.objID(obj)}
+		name := &py.Name{Id: c.objID(obj)}
+		if c.Defs[ident] == nil && c.isBoxed(obj) {
+			return &py.Attribute{Value: name, Attr: py.Identifier("value")}
+		}
+		return name
 	}
 }
 
@@ -61,7 +70,7 @@ func binOp(t token.Token) (py.Operator, bool) {
 	case token.MUL:
 		return py.Mult, true
 	case token.QUO:
-		return py.FloorDiv, true
+		return py.Div, true
 	case token.REM:
 		return py.Mod, true
 	case token.AND:
@@ -90,38 +99,354 @@ func boolOp(t token.Token) (py.BoolOp, bool) {
 }
 
 func (c *exprCompiler) compileBinaryExpr(expr *ast.BinaryExpr) py.Expr {
+	if expr.Op == token.EQL || expr.Op == token.NEQ {
+		// x == nil becomes x is <zero value of x's type>
+		x, y := expr.X, expr.Y
+		if c.isNil(x) {
+			x, y = y, x
+		}
+		if c.isNil(y) {
+			op := py.Is
+			if expr.Op == token.NEQ {
+				op = py.IsNot
+			}
+			return &py.Compare{
+				Left:        c.compileExpr(x),
+				Ops:         []py.CmpOp{op},
+				Comparators: []py.Expr{c.zeroValue(c.TypeOf(x))}}
+		}
+	}
 	if pyCmp, ok := comparator(expr.Op); ok {
+		if expr.Op == token.EQL || expr.Op == token.NEQ {
+			// Comparing an interface value with a non-interface value
+			// converts the latter to the interface type.
+			x, y := c.TypeOf(expr.X), c.TypeOf(expr.Y)
+			if types.IsInterface(x) != types.IsInterface(y) {
+				left, right := c.compileOperands(expr)
+				if types.IsInterface(x) {
+					right = c.toInterface(right, y)
+				} else {
+					left = c.toInterface(left, x)
+				}
+				return &py.Compare{Left: left, Ops: []py.CmpOp{pyCmp}, Comparators: []py.Expr{right}}
+			}
+		}
+		if ptr, ok := c.TypeOf(expr.X).Underlying().(*types.Pointer); ok && isValueType(ptr.Elem()) {
+			// Pointers to structs and arrays are equal if they are the same
+			// object, whereas the == operator compares the values.
+			pyCmp = py.Is
+			if expr.Op == token.NEQ {
+				pyCmp = py.IsNot
+			}
+		}
+		left, right := c.compileOperands(expr)
 		return &py.Compare{
-			Left:        c.compileExpr(expr.X),
+			Left:        left,
 			Ops:         []py.CmpOp{pyCmp},
-			Comparators: []py.Expr{c.compileExpr(expr.Y)}}
-	}
-	if pyOp, ok := binOp(expr.Op); ok {
-		return &py.BinOp{Left: c.compileExpr(expr.X),
-			Right: c.compileExpr(expr.Y),
-			Op:    pyOp}
+			Comparators: []py.Expr{right}}
 	}
 	if pyBoolOp, ok := boolOp(expr.Op); ok {
-		return &py.BoolOpExpr{
-			Values: []py.Expr{c.compileExpr(expr.X), c.compileExpr(expr.Y)},
-			Op:     pyBoolOp}
+		x := c.compileExpr(expr.X)
+		mark := len(c.stmts)
+		y := c.compileExpr(expr.Y)
+		if !c.hoistedSince(mark) {
+			return &py.BoolOpExpr{Values: []py.Expr{x, y}, Op: pyBoolOp}
+		}
+		return c.shortCircuit(pyBoolOp, x, mark, y)
 	}
-	if expr.Op == token.AND_NOT {
-		return &py.BinOp{Left: c.compileExpr(expr.X),
-			Right: &py.UnaryOpExpr{Op: py.Invert, Operand: c.compileExpr(expr.Y)},
-			Op:    py.BitAnd}
+	if _, ok := binOp(expr.Op); ok || expr.Op == token.AND_NOT {
+		if tv := c.Types[expr]; tv.Value != nil && tv.Value.Kind() == constant.Int &&
+			(expr.Op == token.QUO || expr.Op == token.REM) {
+			// Python has no truncated integer division operator so emit
+			// the value of the constant expression instead
+			return &py.Num{N: tv.Value.ExactString()}
+		}
+		typ := c.TypeOf(expr)
+		if c.isConst(expr) {
+			// Constant expressions cannot overflow
+			typ = nil
+		}
+		left, right := c.compileOperands(expr)
+		return c.arith(expr.Op, typ, left, right)
 	}
 	panic(c.err(expr, "unknown BinaryExpr Op: %v", expr.Op))
 }
 
+// shortCircuit compiles x and y, or x or y, where compiling y hoisted the
+// statements since there were mark statements. y is evaluated only if x does
+// not decide the result, so neither are its statements:
+//
+//	t = x
+//	if t:
+//	    ...
+//	    t = y
+func (c *exprCompiler) shortCircuit(op py.BoolOp, x py.Expr, mark int, y py.Expr) py.Expr {
+	body := append([]py.Stmt{}, c.stmts[mark:]...)
+	c.stmts = c.stmts[:mark]
+	tmp := &py.Name{Id: c.tempID("t")}
+	c.addStmt(&py.Assign{Targets: []py.Expr{tmp}, Value: x})
+	var test py.Expr = tmp
+	if op == py.Or {
+		test = &py.UnaryOpExpr{Op: py.Not, Operand: tmp}
+	}
+	body = append(body, &py.Assign{Targets: []py.Expr{tmp}, Value: y})
+	c.addStmt(&py.If{Test: test, Body: body})
+	return tmp
+}
+
+// compileOperands compiles the operands of a binary expression in order.
+func (c *exprCompiler) compileOperands(expr *ast.BinaryExpr) (py.Expr, py.Expr) {
+	operands := c.compileExprs([]ast.Expr{expr.X, expr.Y})
+	return operands[0], operands[1]
+}
+
+// arith compiles the arithmetic operation x op y, where the result has type
+// typ. typ is nil if the result is a constant, which cannot overflow.
+func (c *exprCompiler) arith(op token.Token, typ types.Type, x, y py.Expr) py.Expr {
+	t := intType(typ)
+	signed := t != nil && t.Info()&types.IsUnsigned == 0
+	switch op {
+	case token.QUO:
+		if signed {
+			// Truncated division, which can overflow for the most negative value
+			return methodCall(runtimeAttr(types.Typ[t.Kind()].Name()), "quo", x, y)
+		} else if t != nil {
+			return &py.BinOp{Left: x, Op: py.FloorDiv, Right: y}
+		}
+	case token.REM:
+		if signed {
+			// The remainder has the sign of the dividend
+			return methodCall(runtimeAttr(types.Typ[t.Kind()].Name()), "rem", x, y)
+		}
+	case token.ADD:
+		if isString(typ) {
+			// The bytes of a character may be split between x and y
+			return runtimeCall("concat", x, y)
+		}
+	case token.AND_NOT: // no &^ in python so x &^ y becomes x & ~y
+		return &py.BinOp{Left: x, Op: py.BitAnd, Right: &py.UnaryOpExpr{Op: py.Invert, Operand: y}}
+	}
+	pyOp, _ := binOp(op)
+	var value py.Expr = &py.BinOp{Left: x, Op: pyOp, Right: y}
+	if overflows(pyOp) {
+		value = c.wrapInt(value, typ)
+	}
+	return value
+}
+
+// compileConversion compiles the conversion T(x).
+func (c *exprCompiler) compileConversion(expr *ast.CallExpr) py.Expr {
+	arg := expr.Args[0]
+	to, from := c.TypeOf(expr), c.TypeOf(arg)
+	if c.isConst(expr) {
+		if intType(to) != nil && c.isConst(arg) {
+			return c.compileExpr(arg)
+		}
+		return c.compileConst(expr)
+	}
+	switch t := to.Underlying().(type) {
+	case *types.Basic:
+		x := c.compileExpr(arg)
+		f, _ := from.Underlying().(*types.Basic)
+		switch {
+		case t.Info()&types.IsInteger != 0:
+			if f != nil && f.Info()&types.IsFloat != 0 {
+				// Truncated towards zero
+				return methodCall(runtimeAttr(t.Name()), "trunc", x)
+			}
+			// Integer conversions truncate or sign-extend
+			return c.wrapInt(x, t)
+		case t.Info()&types.IsFloat != 0, t.Info()&types.IsComplex != 0:
+			if f != nil && f.Kind() == t.Kind() {
+				return x
+			}
+			// Rounded to the precision of t
+			return runtimeCall(t.Name(), x)
+		case t.Info()&types.IsString != 0:
+			switch {
+			case f != nil && f.Info()&types.IsInteger != 0:
+				return runtimeCall("runeToString", x)
+			case isSlice(from) && isRune(from.Underlying().(*types.Slice).Elem()):
+				return runtimeCall("runesToString", x)
+			case isSlice(from):
+				return runtimeCall("bytesToString", x)
+			}
+		}
+		return x
+	case *types.Slice:
+		if isString(from) {
+			if isRune(t.Elem()) {
+				return runtimeCall("stringToRunes", c.compileExpr(arg))
+			}
+			return runtimeCall("stringToBytes", c.compileExpr(arg))
+		}
+	case *types.Array:
+		if isSlice(from) {
+			args := []py.Expr{c.compileExpr(arg), &py.Num{N: strconv.FormatInt(t.Len(), 10)}}
+			if f := c.copyFunc(t.Elem()); f != nil {
+				args = append(args, f)
+			}
+			return runtimeCall("sliceToArray", args...)
+		}
+	case *types.Pointer:
+		if isSlice(from) {
+			panic(c.err(expr, "conversion of slice to array pointer not supported"))
+		}
+	case *types.Struct:
+		if named, ok := to.(*types.Named); ok && !types.Identical(to, from) {
+			// A value of the class of the named type, with copies of the fields
+			x := c.compileOnce(arg)
+			var fields []py.Expr
+			for i := 0; i < t.NumFields(); i++ {
+				field := t.Field(i)
+				value := &py.Attribute{Value: x, Attr: fieldID(field)}
+				fields = append(fields, c.copyValue(value, field.Type()))
+			}
+			return &py.Call{Func: &py.Name{Id: c.objID(named.Obj())}, Args: fields}
+		}
+	}
+	// Conversions between types with the same representation, and to
+	// interfaces
+	return c.compileValue(arg, to)
+}
+
+// compileConst compiles the value of the constant expression expr.
+func (c *exprCompiler) compileConst(expr ast.Expr) py.Expr {
+	tv := c.Types[expr]
+	if value := constValue(tv.Value, tv.Type); value != nil {
+		return value
+	}
+	panic(c.err(expr, "constant of type %s", tv.Type))
+}
+
+// constValue compiles the constant value of type typ, or returns nil if typ
+// is not a basic type.
+func constValue(value constant.Value, typ types.Type) py.Expr {
+	t, ok := typ.Underlying().(*types.Basic)
+	if !ok {
+		return nil
+	}
+	switch {
+	case t.Info()&types.IsBoolean != 0:
+		if constant.BoolVal(value) {
+			return pyTrue
+		}
+		return pyFalse
+	case t.Info()&types.IsString != 0:
+		return &py.Str{S: quote(constant.StringVal(value))}
+	case t.Info()&types.IsInteger != 0:
+		return &py.Num{N: constant.ToInt(value).ExactString()}
+	case t.Info()&types.IsFloat != 0:
+		return &py.Num{N: formatFloat(constant.ToFloat(value), t)}
+	case t.Info()&types.IsComplex != 0:
+		re := formatFloat(constant.Real(value), t)
+		im := formatFloat(constant.Imag(value), t)
+		return &py.Call{Func: pyComplex, Args: []py.Expr{&py.Num{N: re}, &py.Num{N: im}}}
+	}
+	return nil
+}
+
+// isExactLit reports whether expr is a literal that Python reads as a value
+// of type typ, so it can be compiled as written rather than folded.
+func isExactLit(expr ast.Expr, typ types.Type) bool {
+	lit, ok := unparen(expr).(*ast.BasicLit)
+	if !ok {
+		return false
+	}
+	t, ok := typ.Underlying().(*types.Basic)
+	if !ok || isSinglePrecision(t) {
+		return false
+	}
+	switch lit.Kind {
+	case token.INT:
+		// Python has no 0600 octal literals
+		legacyOctal := len(lit.Value) > 1 && lit.Value[0] == '0' && '0' <= lit.Value[1] && lit.Value[1] <= '9'
+		return t.Info()&types.IsInteger != 0 && !legacyOctal
+	case token.CHAR:
+		return t.Info()&types.IsInteger != 0
+	case token.FLOAT:
+		// Python has no hexadecimal float literals
+		return t.Info()&types.IsFloat != 0 && !strings.HasPrefix(strings.ToLower(lit.Value), "0x")
+	case token.IMAG:
+		return t.Info()&types.IsComplex != 0 && !strings.HasPrefix(strings.ToLower(lit.Value), "0x")
+	case token.STRING:
+		return true
+	}
+	return false
+}
+
+// hasByteEscape reports whether the interpreted string literal lit escapes
+// a byte above 0x7f, which Python would read as a code point.
+func hasByteEscape(lit string) bool {
+	for i := 0; i < len(lit)-2; i++ {
+		if lit[i] == '\\' {
+			i++
+			if lit[i] == 'x' && strings.IndexByte("89abcdefABCDEF", lit[i+1]) >= 0 || lit[i] == '2' || lit[i] == '3' {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// quote returns a Python string literal for s. Bytes of s that are not valid
+// UTF-8 are escaped as the surrogates that the runtime uses to represent them.
+func quote(s string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size == 1 {
+			fmt.Fprintf(&b, `\udc%02x`, s[i])
+		} else {
+			q := strconv.Quote(s[i : i+size])
+			b.WriteString(q[1 : len(q)-1])
+		}
+		i += size
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// formatFloat formats the float constant value, rounded to the precision of
+// the float or complex type t, as a Python float literal. The literal is
+// the exact rounded value, as Python floats have double precision.
+func formatFloat(value constant.Value, t *types.Basic) string {
+	f, _ := constant.Float64Val(value)
+	if isSinglePrecision(t) {
+		f32, _ := constant.Float32Val(value)
+		f = float64(f32)
+	}
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if !strings.ContainsAny(s, ".e") {
+		s += ".0"
+	}
+	return s
+}
+
+// isSinglePrecision reports whether typ is float32 or complex64, or a type
+// whose underlying type is one of them.
+func isSinglePrecision(typ types.Type) bool {
+	t, ok := typ.Underlying().(*types.Basic)
+	return ok && (t.Kind() == types.Float32 || t.Kind() == types.Complex64)
+}
+
 func (c *exprCompiler) compileBasicLit(expr *ast.BasicLit) py.Expr {
 	switch expr.Kind {
 	case token.INT, token.FLOAT:
 		return &py.Num{N: expr.Value}
 	case token.CHAR:
-		return &py.Str{S: expr.Value}
+		// A rune literal is an integer
+		return &py.Call{Func: pyOrd, Args: []py.Expr{&py.Str{S: expr.Value}}}
 	case token.STRING:
-		return &py.Str{S: expr.Value}
+		if strings.HasPrefix(expr.Value, `"`) && !hasByteEscape(expr.Value) {
+			return &py.Str{S: expr.Value}
+		}
+		s, err := strconv.Unquote(expr.Value)
+		if err != nil {
+			panic(c.err(expr, "invalid string literal: %v", err))
+		}
+		return &py.Str{S: quote(s)}
 	case token.IMAG:
 		return &py.Num{N: strings.Replace(expr.Value, "i", "j", 1)}
 	}
@@ -133,74 +458,119 @@ func (c *exprCompiler) compileUnaryExpr(expr *ast.UnaryExpr) py.Expr {
 	case token.NOT:
 		return &py.UnaryOpExpr{Op: py.Not, Operand: c.compileExpr(expr.X)}
 	case token.AND: // address of
-		return c.compileExpr(expr.X)
+		return c.compileAddress(expr.X)
 	case token.ADD:
 		return &py.UnaryOpExpr{Op: py.UAdd, Operand: c.compileExpr(expr.X)}
 	case token.SUB:
-		return &py.UnaryOpExpr{Op: py.USub, Operand: c.compileExpr(expr.X)}
+		var value py.Expr = &py.UnaryOpExpr{Op: py.USub, Operand: c.compileExpr(expr.X)}
+		if !c.isConst(expr) {
+			value = c.wrapInt(value, c.TypeOf(expr))
+		}
+		return value
 	case token.XOR:
-		return &py.UnaryOpExpr{Op: py.Invert, Operand: c.compileExpr(expr.X)}
+		var value py.Expr = &py.UnaryOpExpr{Op: py.Invert, Operand: c.compileExpr(expr.X)}
+		if t := intType(c.TypeOf(expr)); t != nil && t.Info()&types.IsUnsigned != 0 && !c.isConst(expr) {
+			// ^x is negative in Python for unsigned x
+			value = c.wrapInt(value, t)
+		}
+		return value
+	case token.ARROW:
+		return methodCall(c.compileExpr(expr.X), "recv")
 	}
 	panic(c.err(expr, "unknown UnaryExpr: %v", expr.Op))
 }
 
 func (c *exprCompiler) compileCompositeLit(expr *ast.CompositeLit) py.Expr {
-	switch typ := c.TypeOf(expr).(type) {
-	case *types.Named:
+	typ := c.TypeOf(expr)
+	if ptr, ok := typ.(*types.Pointer); ok {
+		// &T is elided in e.g. []*T{{x, y}}
+		typ = ptr.Elem()
+	}
+	switch t := typ.Underlying().(type) {
+	case *types.Struct:
+		named, ok := typ.(*types.Named)
+		if !ok {
+			if t.NumFields() == 0 {
+				return c.zeroValue(t)
+			}
+			panic(c.err(expr, "composite literal of unnamed struct type"))
+		}
 		var args []py.Expr
 		var keywords []py.Keyword
 		if len(expr.Elts) > 0 {
 			if _, ok := expr.Elts[0].(*ast.KeyValueExpr); ok {
-				for _, elt := range expr.Elts {
+				values := c.compileInOrder(expr.Elts, func(_ int, elt ast.Expr) py.Expr {
 					kv := elt.(*ast.KeyValueExpr)
-					id := c.identifier(kv.Key.(*ast.Ident))
-					keyword := py.Keyword{
-						Arg:   &id,
-						Value: c.compileExpr(kv.Value)}
-					keywords = append(keywords, keyword)
-				}
-			} else {
-				args = make([]py.Expr, len(expr.Elts))
+					return c.compileValue(kv.Value, c.ObjectOf(kv.Key.(*ast.Ident)).Type())
+				})
+				ids := c.initArgs(t)
 				for i, elt := range expr.Elts {
-					args[i] = c.compileExpr(elt)
+					field := c.ObjectOf(elt.(*ast.KeyValueExpr).Key.(*ast.Ident))
+					for j := 0; j < t.NumFields(); j++ {
+						if t.Field(j) == field {
+							keywords = append(keywords, py.Keyword{Arg: &ids[j], Value: values[i]})
+						}
+					}
 				}
+			} else {
+				args = c.compileInOrder(expr.Elts, func(i int, elt ast.Expr) py.Expr {
+					return c.compileValue(elt, t.Field(i).Type())
+				})
 			}
 		}
 		return &py.Call{
-			Func:     &py.Name{Id: c.objID(typ.Obj())},
+			Func:     &py.Name{Id: c.objID(named.Obj())},
 			Args:     args,
 			Keywords: keywords,
 		}
 	case *types.Array:
-		elts := make([]py.Expr, len(expr.Elts))
-		for i, elt := range expr.Elts {
-			elts[i] = c.compileExpr(elt)
+		elts := c.compileInOrder(expr.Elts, func(_ int, elt ast.Expr) py.Expr {
+			return c.compileValue(elt, t.Elem())
+		})
+		for int64(len(elts)) < t.Len() {
+			elts = append(elts, c.zeroValue(t.Elem()))
 		}
 		return &py.List{Elts: elts}
 	case *types.Slice:
-		elts := make([]py.Expr, len(expr.Elts))
-		for i, elt := range expr.Elts {
-			elts[i] = c.compileExpr(elt)
-		}
-		return &py.List{Elts: elts}
+		elts := c.compileInOrder(expr.Elts, func(_ int, elt ast.Expr) py.Expr {
+			return c.compileValue(elt, t.Elem())
+		})
+		return runtimeCall("Slice", &py.List{Elts: elts})
 	case *types.Map:
-		keys := make([]py.Expr, len(expr.Elts))
-		values := make([]py.Expr, len(expr.Elts))
-		for i, elt := range expr.Elts {
+		var operands []ast.Expr
+		for _, elt := range expr.Elts {
 			kv := elt.(*ast.KeyValueExpr)
-			keys[i] = c.compileExpr(kv.Key)
-			values[i] = c.compileExpr(kv.Value)
+			operands = append(operands, kv.Key, kv.Value)
 		}
-		return &py.Dict{Keys: keys, Values: values}
+		compiled := c.compileInOrder(operands, func(i int, operand ast.Expr) py.Expr {
+			if i%2 == 0 {
+				return c.compileKey(operand, t.Key())
+			}
+			return c.compileValue(operand, t.Elem())
+		})
+		dict := &py.Dict{Keys: make([]py.Expr, len(expr.Elts)), Values: make([]py.Expr, len(expr.Elts))}
+		for i := range expr.Elts {
+			dict.Keys[i], dict.Values[i] = compiled[2*i], compiled[2*i+1]
+		}
+		return dict
 	default:
 		panic(c.err(expr, "Unknown composite literal type: %T", typ))
 	}
 }
 
+// selectorID returns the attribute name of the field or method selected by
+// sel.
+func (c *Compiler) selectorID(sel *ast.SelectorExpr) py.Identifier {
+	if selection, ok := c.Selections[sel]; ok {
+		return fieldID(selection.Obj())
+	}
+	return c.identifier(sel.Sel)
+}
+
 func (c *exprCompiler) compileSelectorExpr(expr *ast.SelectorExpr) py.Expr {
 	return &py.Attribute{
 		Value: c.compileExpr(expr.X),
-		Attr:  c.identifier(expr.Sel),
+		Attr:  c.selectorID(expr),
 	}
 }
 
@@ -209,6 +579,38 @@ func isString(typ types.Type) bool {
 	return ok && t.Info()&types.IsString != 0
 }
 
+func isSlice(typ types.Type) bool {
+	_, ok := typ.Underlying().(*types.Slice)
+	return ok
+}
+
+// isRune reports whether typ is rune, or another type whose underlying type
+// is int32.
+func isRune(typ types.Type) bool {
+	t, ok := typ.Underlying().(*types.Basic)
+	return ok && t.Kind() == types.Int32
+}
+
+func isMap(typ types.Type) bool {
+	_, ok := typ.Underlying().(*types.Map)
+	return ok
+}
+
+func isChan(typ types.Type) bool {
+	_, ok := typ.Underlying().(*types.Chan)
+	return ok
+}
+
+// mapOf returns the type of the map that expr is an entry of, or nil if expr
+// is not an index expression on a map.
+func (c *Compiler) mapOf(expr ast.Expr) *types.Map {
+	if index, ok := unparen(expr).(*ast.IndexExpr); ok {
+		t, _ := c.TypeOf(index.X).Underlying().(*types.Map)
+		return t
+	}
+	return nil
+}
+
 var builtin = struct {
 	append  types.Object
 	cap     types.Object
@@ -249,7 +651,13 @@ var builtin = struct {
 	nil:     types.Universe.Lookup("nil"),
 }
 
+// emptyInterface is interface{}, the parameter type of panic.
+var emptyInterface = types.NewInterfaceType(nil, nil).Complete()
+
 func (c *exprCompiler) compileCallExpr(expr *ast.CallExpr) py.Expr {
+	if c.Types[expr.Fun].IsType() {
+		return c.compileConversion(expr)
+	}
 
 	switch fun := expr.Fun.(type) {
 	case *ast.Ident:
@@ -258,30 +666,46 @@ func (c *exprCompiler) compileCallExpr(expr *ast.CallExpr) py.Expr {
 			typ := expr.Args[0]
 			switch t := c.TypeOf(typ).Underlying().(type) {
 			case *types.Slice:
-				length := expr.Args[1]
-				// This is a list comprehension rather than [<nil value>] * length
-				// because in the case when T is not a primitive type,
-				// every element in the list needs to be a different object.
-				return &py.ListComp{
-					Elt: c.zeroValue(t.Elem()),
-					Generators: []py.Comprehension{
-						py.Comprehension{
-							Target: &py.Name{Id: py.Identifier("_")},
-							Iter: &py.Call{
-								Func: pyRange,
-								Args: []py.Expr{c.compileExpr(length)},
+				// The backing array is a list comprehension rather than
+				// [<zero value>] * n because in the case when T is not a
+				// primitive type, every element needs to be a different object.
+				zeros := func(n ast.Expr) py.Expr {
+					return &py.ListComp{
+						Elt: c.zeroValue(t.Elem()),
+						Generators: []py.Comprehension{
+							py.Comprehension{
+								Target: &py.Name{Id: py.Identifier("_")},
+								Iter: &py.Call{
+									Func: pyRange,
+									Args: []py.Expr{c.compileExpr(n)},
+								},
 							},
 						},
-					},
+					}
 				}
+				if len(expr.Args) > 2 {
+					// make([]T, length, capacity)
+					length := c.compileExpr(expr.Args[1])
+					return runtimeCall("Slice", zeros(expr.Args[2]), &py.Num{N: "0"}, length)
+				}
+				return runtimeCall("Slice", zeros(expr.Args[1]))
 			case *types.Map:
 				return &py.Dict{}
+			case *types.Chan:
+				var size py.Expr = &py.Num{N: "0"}
+				if len(expr.Args) > 1 {
+					size = c.compileExpr(expr.Args[1])
+				}
+				return runtimeCall("Chan", size, c.zeroValue(t.Elem()))
 			default:
 				panic(c.err(expr, "bad type in make(): %T", t))
 			}
 		case builtin.new:
-			typ := expr.Args[0]
-			return c.zeroValue(c.TypeOf(typ))
+			typ := c.TypeOf(expr.Args[0])
+			if isValueType(typ) {
+				return c.zeroValue(typ)
+			}
+			return runtimeCall("Box", c.zeroValue(typ))
 		case builtin.complex:
 			return &py.Call{
 				Func: pyComplex,
@@ -291,19 +715,72 @@ func (c *exprCompiler) compileCallExpr(expr *ast.CallExpr) py.Expr {
 			return &py.Attribute{Value: c.compileExpr(expr.Args[0]), Attr: py.Identifier("real")}
 		case builtin.imag:
 			return &py.Attribute{Value: c.compileExpr(expr.Args[0]), Attr: py.Identifier("imag")}
+		case builtin.close:
+			return methodCall(c.compileExpr(expr.Args[0]), "close")
+		case builtin.panic:
+			return runtimeCall("panic", c.compileValue(expr.Args[0], emptyInterface))
+		case builtin.print, builtin.println:
+			args := c.compileExprs(expr.Args)
+			for i, arg := range expr.Args {
+				switch t := c.TypeOf(arg); {
+				case types.IsInterface(t):
+					// Interface values print as the addresses of their type
+					// and value
+					args[i] = runtimeCall("printIface", args[i])
+				case isSinglePrecision(t):
+					args[i] = runtimeCall("printFloat32", args[i])
+				}
+			}
+			return runtimeCall(fun.Name, args...)
+		case builtin.recover:
+			return runtimeCall("recover")
+		case builtin.delete:
+			m, key := c.compileMapIndex(&ast.IndexExpr{X: expr.Args[0], Index: expr.Args[1]})
+			return runtimeCall("delete", m, key)
+		case builtin.append:
+			// The zero value pads the capacity of a grown slice
+			elem := c.TypeOf(expr).Underlying().(*types.Slice).Elem()
+			if expr.Ellipsis.IsValid() {
+				args := append(c.compileExprs(expr.Args), c.zeroValue(elem))
+				if f := c.copyFunc(elem); f != nil {
+					args = append(args, f)
+				}
+				return runtimeCall("appendSlice", args...)
+			}
+			args := c.compileInOrder(expr.Args, func(i int, arg ast.Expr) py.Expr {
+				if i == 0 {
+					return c.compileExpr(arg)
+				}
+				return c.compileValue(arg, elem)
+			})
+			call := runtimeCall("append", append([]py.Expr{args[0], c.zeroValue(elem)}, args[1:]...)...)
+			if f := c.copyFunc(elem); f != nil {
+				copyElem := py.Identifier("copyElem")
+				call.Keywords = []py.Keyword{{Arg: &copyElem, Value: f}}
+			}
+			return call
+		case builtin.copy:
+			args := c.compileExprs(expr.Args)
+			if dst, ok := c.TypeOf(expr.Args[0]).Underlying().(*types.Slice); ok {
+				if f := c.copyFunc(dst.Elem()); f != nil {
+					args = append(args, f)
+				}
+			}
+			return runtimeCall("copy", args...)
 		case builtin.len, builtin.cap:
 			t := c.TypeOf(expr.Args[0])
 			switch {
+			case c.ObjectOf(fun) == builtin.cap && (isChan(t) || isSlice(t)):
+				return runtimeCall("cap", c.compileExpr(expr.Args[0]))
 			case isString(t):
+				// The length in bytes
 				return &py.Call{
 					Func: pyLen,
-					Args: []py.Expr{
-						&py.Call{
-							Func: &py.Attribute{Value: c.compileExpr(expr.Args[0]), Attr: py.Identifier("encode")},
-							Args: []py.Expr{&py.Str{S: `"utf-8"`}},
-						},
-					},
+					Args: []py.Expr{runtimeCall("encode", c.compileExpr(expr.Args[0]))},
 				}
+			case isMap(t) || isChan(t):
+				// Nil maps and channels are None
+				return runtimeCall("length", c.compileExpr(expr.Args[0]))
 			default:
 				return &py.Call{
 					Func: pyLen,
@@ -311,30 +788,229 @@ func (c *exprCompiler) compileCallExpr(expr *ast.CallExpr) py.Expr {
 				}
 			}
 		}
-	case *ast.ArrayType, *ast.ChanType, *ast.FuncType,
-		*ast.InterfaceType, *ast.MapType, *ast.StructType:
-		// TODO implement type conversions
-		return c.compileExpr(expr.Args[0])
+	case *ast.SelectorExpr:
+		if method := c.namedTypeMethod(fun); method != nil {
+			// T.M(x, args...)
+			recv := c.compileMethodReceiver(fun)
+			args := c.compileArgsAfter(expr, fun.X, &recv)
+			return &py.Call{Func: method, Args: append([]py.Expr{recv}, args...)}
+		}
 	}
-	return &py.Call{
-		Func: c.compileExpr(expr.Fun),
-		Args: c.compileExprs(expr.Args),
+	f := c.compileExpr(expr.Fun)
+	args := c.compileArgsAfter(expr, expr.Fun, &f)
+	return &py.Call{Func: f, Args: args}
+}
+
+// compileArgsAfter compiles the arguments of call after compiling operand,
+// the function or receiver of the call, to value. Go evaluates operand
+// first, so value is assigned to a temporary if the arguments hoist
+// statements.
+func (c *exprCompiler) compileArgsAfter(call *ast.CallExpr, operand ast.Expr, value *py.Expr) []py.Expr {
+	mark := len(c.stmts)
+	args := c.compileCallArgs(call)
+	if c.hoistedSince(mark) && c.hasCall(operand) {
+		*value = c.spill(mark, *value)
 	}
+	return args
+}
+
+// namedTypeMethod returns the method M of the class T if sel is x.M and T is
+// a named type whose values are not instances of T, such as a named int or
+// slice type, or nil otherwise.
+func (c *exprCompiler) namedTypeMethod(sel *ast.SelectorExpr) py.Expr {
+	selection, ok := c.Selections[sel]
+	if !ok || selection.Kind() != types.MethodVal || types.IsInterface(selection.Recv()) {
+		return nil
+	}
+	recv := selection.Obj().Type().(*types.Signature).Recv().Type()
+	if ptr, ok := recv.(*types.Pointer); ok {
+		recv = ptr.Elem()
+	}
+	named, ok := recv.(*types.Named)
+	if !ok {
+		return nil
+	}
+	if _, ok := named.Underlying().(*types.Struct); ok {
+		return nil
+	}
+	return &py.Attribute{Value: &py.Name{Id: c.objID(named.Obj())}, Attr: fieldID(selection.Obj())}
+}
+
+// compileMethodReceiver compiles the receiver x of the method call x.M(),
+// taking its address or dereferencing it to match the method's receiver.
+func (c *exprCompiler) compileMethodReceiver(sel *ast.SelectorExpr) py.Expr {
+	recv := c.Selections[sel].Obj().Type().(*types.Signature).Recv().Type()
+	_, ptrRecv := recv.(*types.Pointer)
+	_, ptrX := c.TypeOf(sel.X).Underlying().(*types.Pointer)
+	switch {
+	case ptrRecv && !ptrX:
+		return c.compileAddress(sel.X)
+	case !ptrRecv && ptrX:
+		x := c.compileExpr(sel.X)
+		if isValueType(recv) {
+			return x
+		}
+		return &py.Attribute{Value: x, Attr: py.Identifier("value")}
+	}
+	return c.compileExpr(sel.X)
 }
 func (c *exprCompiler) compileSliceExpr(slice *ast.SliceExpr) py.Expr {
-	return &py.Subscript{
-		Value: c.compileExpr(slice.X),
-		Slice: &py.RangeSlice{
-			Lower: c.compileExpr(slice.Low),
-			Upper: c.compileExpr(slice.High),
-		}}
+	operands := []ast.Expr{slice.X}
+	for _, index := range []ast.Expr{slice.Low, slice.High, slice.Max} {
+		if index != nil {
+			operands = append(operands, index)
+		}
+	}
+	compiled := c.compileExprs(operands)
+	x, indices := compiled[0], compiled[1:]
+	index := func(expr ast.Expr) py.Expr {
+		if expr == nil {
+			return nil
+		}
+		value := indices[0]
+		indices = indices[1:]
+		return value
+	}
+	low, high, capacity := index(slice.Low), index(slice.High), index(slice.Max)
+	typ := c.TypeOf(slice.X).Underlying()
+	if ptr, ok := typ.(*types.Pointer); ok {
+		typ = ptr.Elem().Underlying()
+	}
+	switch typ.(type) {
+	case *types.Basic:
+		// Strings are sliced by byte
+		if low == nil {
+			low = pyNone
+		}
+		if high == nil {
+			high = pyNone
+		}
+		return runtimeCall("sliceString", x, low, high)
+	case *types.Array:
+		// Slicing an array makes a slice that shares its elements
+		x = runtimeCall("Slice", x)
+	}
+
+	// xs[:z] becomes xs.slice(0, z) and xs[y:] becomes xs.slice(y)
+	var args []py.Expr
+	if slice.Low != nil || slice.High != nil || slice.Max != nil {
+		if low == nil {
+			low = &py.Num{N: "0"}
+		}
+		args = append(args, low)
+	}
+	if slice.High != nil || slice.Max != nil {
+		if high == nil {
+			high = pyNone
+		}
+		args = append(args, high)
+	}
+	if slice.Max != nil {
+		args = append(args, capacity)
+	}
+	return methodCall(x, "slice", args...)
 }
 
 func (c *exprCompiler) compileIndexExpr(expr *ast.IndexExpr) py.Expr {
+	if t := c.mapOf(expr); t != nil {
+		// A nil map or a missing key yields the zero value
+		m, key := c.compileMapIndex(expr)
+		return runtimeCall("mapIndex", m, key, c.zeroValue(t.Elem()))
+	}
+	operands := c.compileExprs([]ast.Expr{expr.X, expr.Index})
+	if isString(c.TypeOf(expr.X)) {
+		// Strings are indexed by byte
+		operands[0] = runtimeCall("encode", operands[0])
+	}
 	return &py.Subscript{
-		Value: c.compileExpr(expr.X),
-		Slice: &py.Index{Value: c.compileExpr(expr.Index)},
+		Value: operands[0],
+		Slice: &py.Index{Value: operands[1]},
+	}
+}
+
+// compileMapIndex compiles the map and the key of m[key].
+func (c *exprCompiler) compileMapIndex(expr *ast.IndexExpr) (m, key py.Expr) {
+	t := c.mapOf(expr)
+	operands := c.compileInOrder([]ast.Expr{expr.X, expr.Index}, func(i int, operand ast.Expr) py.Expr {
+		if i == 0 {
+			return c.compileExpr(operand)
+		}
+		return c.compileKey(operand, t.Key())
+	})
+	return operands[0], operands[1]
+}
+
+// compileKey compiles expr, a key of a map with keys of type typ. Python
+// lists are not hashable, so arrays are stored as tuples.
+func (c *exprCompiler) compileKey(expr ast.Expr, typ types.Type) py.Expr {
+	if _, ok := typ.Underlying().(*types.Array); ok {
+		return runtimeCall("arrayKey", c.compileExpr(expr))
+	}
+	return c.compileValue(expr, typ)
+}
+
+// compileTarget compiles expr, the target of an assignment. An entry of a
+// map is stored by subscript.
+func (c *exprCompiler) compileTarget(expr ast.Expr) py.Expr {
+	if c.mapOf(expr) != nil {
+		m, key := c.compileMapIndex(unparen(expr).(*ast.IndexExpr))
+		return &py.Subscript{Value: m, Slice: &py.Index{Value: key}}
+	}
+	return c.compileExpr(expr)
+}
+
+// compileAssignTarget compiles expr, the target of an assignment whose
+// values are compiled after it. Python evaluates the targets of an
+// assignment after its values, but Go evaluates the operands of index
+// expressions and pointer indirections on the left first, so operands that
+// call functions or receive from channels are evaluated into temporaries.
+func (c *exprCompiler) compileAssignTarget(expr ast.Expr) py.Expr {
+	if !c.hasCall(expr) {
+		return c.compileTarget(expr)
+	}
+	switch t := unparen(expr).(type) {
+	case *ast.IndexExpr:
+		x := c.compileOperand(t.X)
+		if m := c.mapOf(t); m != nil {
+			key := c.evalOnce(t.Index, c.compileKey(t.Index, m.Key()))
+			return &py.Subscript{Value: x, Slice: &py.Index{Value: key}}
+		}
+		return &py.Subscript{Value: x, Slice: &py.Index{Value: c.compileOperand(t.Index)}}
+	case *ast.SelectorExpr:
+		return &py.Attribute{Value: c.compileOperand(t.X), Attr: c.selectorID(t)}
+	case *ast.StarExpr:
+		if !isValueType(c.TypeOf(t)) {
+			return &py.Attribute{Value: c.compileOperand(t.X), Attr: py.Identifier("value")}
+		}
 	}
+	return c.compileTarget(expr)
+}
+
+// compileOperand compiles expr, evaluating it into a temporary if it calls
+// a function or receives from a channel.
+func (c *exprCompiler) compileOperand(expr ast.Expr) py.Expr {
+	return c.evalOnce(expr, c.compileExpr(expr))
+}
+
+// evalOnce evaluates value, the compiled expr, into a temporary if expr
+// calls a function or receives from a channel.
+func (c *exprCompiler) evalOnce(expr ast.Expr, value py.Expr) py.Expr {
+	if !c.hasCall(expr) {
+		return value
+	}
+	tmp := &py.Name{Id: c.tempID("t")}
+	c.addStmt(&py.Assign{Targets: []py.Expr{tmp}, Value: value})
+	return tmp
+}
+
+// compileTargets compiles the targets of an assignment from left to right,
+// before its values.
+func (c *exprCompiler) compileTargets(exprs []ast.Expr) []py.Expr {
+	var targets []py.Expr
+	for _, expr := range exprs {
+		targets = append(targets, c.compileAssignTarget(expr))
+	}
+	return targets
 }
 
 func (c *exprCompiler) addStmt(stmt py.Stmt) {
@@ -348,20 +1024,70 @@ func (c *exprCompiler) compileFuncLit(expr *ast.FuncLit) py.Expr {
 	return &py.Name{Id: id}
 }
 
+// compileTypeAssertExpr compiles x.(T), which panics if the dynamic type of
+// x is not T or does not implement the interface type T.
 func (c *exprCompiler) compileTypeAssertExpr(expr *ast.TypeAssertExpr) py.Expr {
-	// TODO
-	return c.compileExpr(expr.X)
+	return runtimeCall("typeAssert", c.compileExpr(expr.X), c.compileType(c.TypeOf(expr.Type)))
 }
 
+// compileStarExpr compiles *p. A pointer to a struct or array is the
+// object itself; other pointers hold the value they point to in an attribute.
 func (c *exprCompiler) compileStarExpr(expr *ast.StarExpr) py.Expr {
-	// TODO
-	return c.compileExpr(expr.X)
+	ptr := c.compileExpr(expr.X)
+	if isValueType(c.TypeOf(expr)) {
+		return ptr
+	}
+	return &py.Attribute{Value: ptr, Attr: py.Identifier("value")}
+}
+
+// compileAddress compiles &x.
+func (c *exprCompiler) compileAddress(x ast.Expr) py.Expr {
+	x = unparen(x)
+	if isValueType(c.TypeOf(x)) {
+		// Structs and arrays are referenced by their Python object
+		return c.compileExpr(x)
+	}
+	switch e := x.(type) {
+	case *ast.Ident:
+		// The variable is boxed
+		return &py.Name{Id: c.identifier(e)}
+	case *ast.StarExpr:
+		return c.compileExpr(e.X)
+	case *ast.SelectorExpr:
+		field := c.compileExpr(e).(*py.Attribute)
+		return runtimeCall("FieldPointer", field.Value, &py.Str{S: fmt.Sprintf("%q", string(field.Attr))})
+	case *ast.IndexExpr:
+		return runtimeCall("IndexPointer", c.compileExpr(e.X), c.compileExpr(e.Index))
+	case *ast.CompositeLit:
+		return runtimeCall("Box", c.compileExpr(e))
+	}
+	panic(c.err(x, "cannot take address of %T", x))
+}
+
+// storesInPlace reports whether assigning to x must update the struct or
+// array that x refers to rather than replace it, because a pointer to it
+// may exist.
+func (c *exprCompiler) storesInPlace(x ast.Expr) bool {
+	if typ := c.TypeOf(x); typ == nil || !isValueType(typ) {
+		return false
+	}
+	switch e := unparen(x).(type) {
+	case *ast.StarExpr:
+		return true
+	case *ast.Ident:
+		return c.addressed[c.ObjectOf(e)]
+	}
+	return false
 }
 
 func (c *exprCompiler) compileExpr(expr ast.Expr) py.Expr {
 	if expr == nil {
 		return nil
 	}
+	if c.isConst(expr) && !isExactLit(expr, c.TypeOf(expr)) {
+		// Folded and converted to the constant's type
+		return c.compileConst(expr)
+	}
 	switch e := expr.(type) {
 	case *ast.UnaryExpr:
 		return c.compileUnaryExpr(e)
@@ -395,9 +1121,17 @@ func (c *exprCompiler) compileExpr(expr ast.Expr) py.Expr {
 	panic(c.err(expr, "unknown Expr: %T", expr))
 }
 
+// compileType compiles a type descriptor of typ. Named types are described by
+// the classes generated for them.
 func (c *exprCompiler) compileType(typ types.Type) py.Expr {
 	var pyExpr py.Expr
 	switch t := typ.(type) {
+	case *types.Named:
+		if t.Obj().Pkg() == nil {
+			// error
+			return runtimeAttr(t.Obj().Name())
+		}
+		return &py.Name{Id: c.objID(t.Obj())}
 	case *types.Array:
 		pyExpr = &py.Call{
 			Func: &py.Attribute{
@@ -420,22 +1154,348 @@ func (c *exprCompiler) compileType(typ types.Type) py.Expr {
 			},
 		}
 	case *types.Basic:
-		pyExpr = &py.Attribute{
-			Value: runtimeModule,
-			Attr:  py.Identifier(types.TypeString(t, nil)),
+		pyExpr = runtimeAttr(types.TypeString(types.Default(t), nil))
+	case *types.Pointer:
+		pyExpr = runtimeCall("pointerType", c.compileType(t.Elem()))
+	case *types.Map:
+		pyExpr = runtimeCall("mapType", c.compileType(t.Key()), c.compileType(t.Elem()))
+	case *types.Chan:
+		args := []py.Expr{c.compileType(t.Elem())}
+		switch t.Dir() {
+		case types.SendOnly:
+			args = append(args, &py.Str{S: `"chan<-"`})
+		case types.RecvOnly:
+			args = append(args, &py.Str{S: `"<-chan"`})
+		}
+		pyExpr = runtimeCall("chanType", args...)
+	case *types.Signature:
+		var params, results []py.Expr
+		for i := 0; i < t.Params().Len(); i++ {
+			params = append(params, c.compileType(t.Params().At(i).Type()))
+		}
+		for i := 0; i < t.Results().Len(); i++ {
+			results = append(results, c.compileType(t.Results().At(i).Type()))
+		}
+		args := []py.Expr{&py.Tuple{Elts: params}, &py.Tuple{Elts: results}}
+		if t.Variadic() {
+			args = append(args, pyTrue)
 		}
+		pyExpr = runtimeCall("funcType", args...)
+	case *types.Struct:
+		var fields []py.Expr
+		for i := 0; i < t.NumFields(); i++ {
+			field := t.Field(i)
+			fields = append(fields, &py.Tuple{Elts: []py.Expr{
+				&py.Str{S: fmt.Sprintf("%q", field.Name())},
+				c.compileType(field.Type()),
+			}})
+		}
+		pyExpr = runtimeCall("structType", &py.Tuple{Elts: fields})
+	case *types.Interface:
+		var methods []py.Expr
+		for i := 0; i < t.NumMethods(); i++ {
+			methods = append(methods, &py.Str{S: fmt.Sprintf("%q", fieldID(t.Method(i)))})
+		}
+		pyExpr = runtimeCall("interfaceType", methods...)
 	default:
 		panic(fmt.Sprintf("%T", t))
 	}
 	return pyExpr
 }
 
+func (c *Compiler) isConst(expr ast.Expr) bool {
+	return c.Types[expr].Value != nil
+}
+
+// intType returns the underlying type of typ if it is a typed integer type,
+// otherwise nil.
+func intType(typ types.Type) *types.Basic {
+	if typ == nil {
+		return nil
+	}
+	t, ok := typ.Underlying().(*types.Basic)
+	if !ok || t.Info()&types.IsInteger == 0 || t.Info()&types.IsUntyped != 0 {
+		return nil
+	}
+	return t
+}
+
+// overflows reports whether op can produce a result outside the range of the
+// integer type of its operands.
+func overflows(op py.Operator) bool {
+	switch op {
+	case py.Add, py.Sub, py.Mult, py.LShift:
+		return true
+	}
+	return false
+}
+
+// wrapInt wraps value, whose Go type is typ, to the range of typ if it is a
+// fixed-width integer type, as Go's integer arithmetic does on overflow.
+func (c *exprCompiler) wrapInt(value py.Expr, typ types.Type) py.Expr {
+	t := intType(typ)
+	if t == nil {
+		return value
+	}
+	return runtimeCall(types.Typ[t.Kind()].Name(), value)
+}
+
+// compileOnce compiles expr so that it can be used more than once without
+// being evaluated more than once, assigning it to a temporary if necessary.
+func (c *exprCompiler) compileOnce(expr ast.Expr) py.Expr {
+	return c.once(expr, c.compileExpr(expr))
+}
+
+// once evaluates value, the compiled expr, into a temporary unless expr is
+// a variable or a constant.
+func (c *exprCompiler) once(expr ast.Expr, value py.Expr) py.Expr {
+	switch unparen(expr).(type) {
+	case *ast.Ident, *ast.BasicLit:
+		return value
+	}
+	if c.isConst(expr) {
+		return value
+	}
+	tmp := &py.Name{Id: c.tempID("t")}
+	c.addStmt(&py.Assign{Targets: []py.Expr{tmp}, Value: value})
+	return tmp
+}
+
+// compileInOrder compiles exprs, the operands of an expression or statement,
+// with compile. Go evaluates the calls and receive operations of the
+// operands from left to right, so if compiling an operand hoists statements
+// that may have side effects, the operands before it that call functions or
+// receive from channels are evaluated into temporaries before the
+// statements.
+func (c *exprCompiler) compileInOrder(exprs []ast.Expr, compile func(i int, expr ast.Expr) py.Expr) []py.Expr {
+	values := make([]py.Expr, len(exprs))
+	spilled := make([]bool, len(exprs))
+	for i, expr := range exprs {
+		mark := len(c.stmts)
+		values[i] = compile(i, expr)
+		if !c.hoistedSince(mark) {
+			continue
+		}
+		for j := 0; j < i; j++ {
+			if !spilled[j] && c.hasCall(exprs[j]) {
+				values[j] = c.spill(mark, values[j])
+				spilled[j] = true
+				mark++
+			}
+		}
+	}
+	return values
+}
+
+// hoistedSince reports whether statements that may have side effects have
+// been hoisted since there were mark statements. Function definitions have
+// none.
+func (c *exprCompiler) hoistedSince(mark int) bool {
+	for _, stmt := range c.stmts[mark:] {
+		if _, ok := stmt.(*py.FunctionDef); !ok {
+			return true
+		}
+	}
+	return false
+}
+
+// spill assigns value to a temporary before the statements hoisted since
+// there were mark statements, and returns the temporary.
+func (c *exprCompiler) spill(mark int, value py.Expr) py.Expr {
+	tmp := &py.Name{Id: c.tempID("t")}
+	stmts := append([]py.Stmt{}, c.stmts[:mark]...)
+	stmts = append(stmts, &py.Assign{Targets: []py.Expr{tmp}, Value: value})
+	c.stmts = append(stmts, c.stmts[mark:]...)
+	return tmp
+}
+
+// hasCall reports whether evaluating expr calls a function or receives from
+// a channel.
+func (c *Compiler) hasCall(expr ast.Expr) bool {
+	found := false
+	ast.Inspect(expr, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.CallExpr:
+			found = found || !c.Types[n.Fun].IsType()
+		case *ast.UnaryExpr:
+			found = found || n.Op == token.ARROW
+		}
+		return !found
+	})
+	return found
+}
+
+func (c *Compiler) isNil(expr ast.Expr) bool {
+	tv, ok := c.Types[expr]
+	return ok && tv.IsNil()
+}
+
+// compileValue compiles expr as a value assigned to a variable, parameter,
+// result or element of type typ. This differs from compileExpr when expr
+// has no type of its own, e.g. nil assigned to a slice, or when expr is a
+// struct or array value, which is copied.
+func (c *exprCompiler) compileValue(expr ast.Expr, typ types.Type) py.Expr {
+	if c.isNil(expr) {
+		return c.zeroValue(typ)
+	}
+	value := c.compileExpr(expr)
+	t := c.TypeOf(expr)
+	if isValueType(t) && !c.isFresh(expr) {
+		value = c.copyValue(value, t)
+	}
+	if typ != nil && types.IsInterface(typ) && !types.IsInterface(t) {
+		if unary, ok := unparen(expr).(*ast.UnaryExpr); ok && unary.Op == token.AND && isStructPointer(t) {
+			// &x is not nil, so is stored as it is
+			return value
+		}
+		value = c.toInterface(value, t)
+	}
+	return value
+}
+
+// toInterface converts value of type typ to an interface value. Values of
+// types that can be told apart by their Python representation are stored
+// as they are. Others are stored with a descriptor of their type.
+func (c *exprCompiler) toInterface(value py.Expr, typ types.Type) py.Expr {
+	typ = types.Default(typ)
+	switch t := typ.(type) {
+	case *types.Basic:
+		switch t.Kind() {
+		case types.Bool, types.Int, types.Float64, types.String, types.Complex128:
+			return value
+		}
+	case *types.Pointer:
+		if isStructPointer(t) {
+			// Stored as they are unless they are nil
+			return runtimeCall("pointerIface", value, c.compileType(typ))
+		}
+	}
+	return runtimeCall("Iface", c.compileType(typ), value)
+}
+
+// isStructPointer reports whether typ is a pointer to a named struct type,
+// whose values are the objects of its class.
+func isStructPointer(typ types.Type) bool {
+	if ptr, ok := typ.(*types.Pointer); ok {
+		if named, ok := ptr.Elem().(*types.Named); ok {
+			_, ok := named.Underlying().(*types.Struct)
+			return ok
+		}
+	}
+	return false
+}
+
+// isValueType reports whether values of typ are copied on assignment.
+// Other types are represented by immutable Python values or by references.
+func isValueType(typ types.Type) bool {
+	switch t := typ.Underlying().(type) {
+	case *types.Struct:
+		_, named := typ.(*types.Named)
+		return named || t.NumFields() > 0
+	case *types.Array:
+		return true
+	}
+	return false
+}
+
+// isFresh reports whether expr makes a new value that no variable refers to,
+// so that it does not need to be copied.
+func (c *Compiler) isFresh(expr ast.Expr) bool {
+	switch e := unparen(expr).(type) {
+	case *ast.CompositeLit:
+		return true
+	case *ast.CallExpr:
+		if c.Types[e.Fun].IsType() {
+			return len(e.Args) == 1 && c.isFresh(e.Args[0])
+		}
+		return true
+	}
+	return false
+}
+
+// copyValue copies value, a struct or array of type typ.
+func (c *Compiler) copyValue(value py.Expr, typ types.Type) py.Expr {
+	if !isValueType(typ) {
+		return value
+	}
+	switch t := typ.Underlying().(type) {
+	case *types.Struct:
+		return methodCall(value, "__copy__")
+	case *types.Array:
+		if !isValueType(t.Elem()) {
+			return &py.Subscript{Value: value, Slice: &py.RangeSlice{}}
+		}
+		elem := &py.Name{Id: py.Identifier("e")}
+		return &py.ListComp{
+			Elt:        c.copyValue(elem, t.Elem()),
+			Generators: []py.Comprehension{{Target: elem, Iter: value}},
+		}
+	}
+	return value
+}
+
+// copyFunc returns a function that copies values of typ, or nil if they are
+// not copied.
+func (c *Compiler) copyFunc(typ types.Type) py.Expr {
+	if !isValueType(typ) {
+		return nil
+	}
+	elem := py.Identifier("e")
+	return &py.Lambda{
+		Args: py.Arguments{Args: []py.Arg{{Arg: elem}}},
+		Body: c.copyValue(&py.Name{Id: elem}, typ),
+	}
+}
+
+// compileCallArgs compiles the arguments of a call to a function or method.
+// The arguments for the final parameter of a variadic function are passed as
+// a slice, as in Go.
+func (c *exprCompiler) compileCallArgs(call *ast.CallExpr) []py.Expr {
+	if tv := c.Types[call.Fun]; tv.IsType() || tv.IsBuiltin() {
+		return c.compileExprs(call.Args)
+	}
+	sig, ok := c.TypeOf(call.Fun).Underlying().(*types.Signature)
+	if !ok {
+		return c.compileExprs(call.Args)
+	}
+	params := sig.Params()
+	if len(call.Args) == 1 && params.Len() > 1 && !sig.Variadic() {
+		// f(g()) where g returns multiple values
+		return []py.Expr{&py.Starred{Value: c.compileExpr(call.Args[0])}}
+	}
+	n := params.Len()
+	variadic := sig.Variadic() && !call.Ellipsis.IsValid()
+	if variadic {
+		n--
+	}
+	values := c.compileInOrder(call.Args, func(i int, arg ast.Expr) py.Expr {
+		if i < n {
+			return c.compileValue(arg, params.At(i).Type())
+		}
+		return c.compileValue(arg, params.At(n).Type().(*types.Slice).Elem())
+	})
+	var args []py.Expr
+	args = append(args, values[:n]...)
+	if variadic {
+		elts := values[n:]
+		if len(elts) == 0 {
+			args = append(args, runtimeAttr("nilSlice"))
+		} else {
+			args = append(args, runtimeCall("Slice", &py.List{Elts: elts}))
+		}
+	}
+	return args
+}
+
 func (c *exprCompiler) compileExprs(exprs []ast.Expr) []py.Expr {
-	var pyExprs []py.Expr
-	for _, result := range exprs {
-		pyExprs = append(pyExprs, c.compileExpr(result))
+	if len(exprs) == 0 {
+		return nil
 	}
-	return pyExprs
+	return c.compileInOrder(exprs, func(_ int, expr ast.Expr) py.Expr {
+		return c.compileExpr(expr)
+	})
 }
 
 func makeTuple(pyExprs ...py.Expr) py.Expr {
@@ -453,9 +1513,56 @@ func (c *exprCompiler) compileExprsTuple(exprs []ast.Expr) py.Expr {
 	return makeTuple(c.compileExprs(exprs)...)
 }
 
+func methodCall(recv py.Expr, name string, args ...py.Expr) *py.Call {
+	return &py.Call{
+		Func: &py.Attribute{Value: recv, Attr: py.Identifier(name)},
+		Args: args,
+	}
+}
+
+func unparen(expr ast.Expr) ast.Expr {
+	for {
+		paren, ok := expr.(*ast.ParenExpr)
+		if !ok {
+			return expr
+		}
+		expr = paren.X
+	}
+}
+
+// compileCommaOk compiles the forms of expression that yield an additional
+// boolean when assigned to two values, e.g. v, ok := <-ch, to a Python
+// expression evaluating to a 2-tuple. It returns nil if expr is not one of them.
+func (c *exprCompiler) compileCommaOk(expr ast.Expr) py.Expr {
+	switch e := unparen(expr).(type) {
+	case *ast.UnaryExpr:
+		if e.Op == token.ARROW {
+			return methodCall(c.compileExpr(e.X), "recvOk")
+		}
+	case *ast.TypeAssertExpr:
+		typ := c.TypeOf(e.Type)
+		args := []py.Expr{c.compileExpr(e.X), c.compileType(typ)}
+		if !types.IsInterface(typ) {
+			args = append(args, c.zeroValue(typ))
+		}
+		return runtimeCall("typeAssertOk", args...)
+	case *ast.IndexExpr:
+		if t := c.mapOf(e); t != nil {
+			m, key := c.compileMapIndex(e)
+			args := []py.Expr{m, key, c.zeroValue(t.Elem())}
+			if f := c.copyFunc(t.Elem()); f != nil {
+				args = append(args, f)
+			}
+			return runtimeCall("mapIndexOk", args...)
+		}
+	}
+	return nil
+}
+
 func (c *exprCompiler) compileCaseClauseTest(caseClause *ast.CaseClause, tag py.Expr) py.Expr {
 	var tests []py.Expr
 	for _, expr := range caseClause.List {
+		mark := len(c.stmts)
 		var test py.Expr
 		if tag != nil {
 			test = &py.Compare{
@@ -465,11 +1572,22 @@ func (c *exprCompiler) compileCaseClauseTest(caseClause *ast.CaseClause, tag py.
 		} else {
 			test = c.compileExpr(expr)
 		}
+		if len(tests) > 0 && c.hoistedSince(mark) {
+			// The expressions are evaluated until one matches
+			test = c.shortCircuit(py.Or, makeOr(tests), mark, test)
+			tests = nil
+		}
 		tests = append(tests, test)
 	}
-	if len(tests) == 0 {
+	return makeOr(tests)
+}
+
+// makeOr returns the disjunction of tests, or nil if there are none.
+func makeOr(tests []py.Expr) py.Expr {
+	switch len(tests) {
+	case 0:
 		return nil
-	} else if len(tests) == 1 {
+	case 1:
 		return tests[0]
 	}
 	return &py.BoolOpExpr{Op: py.Or, Values: tests}
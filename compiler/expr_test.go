@@ -25,12 +25,32 @@ var (
 type U struct{}
 type IntSlice []int
 
+func (s IntSlice) Len() int { return len(s) }
+func (s *IntSlice) Push(x int) int { return 0 }
+
+type Lener interface{ Len() int }
+
 var (
 	b0, b1 bool
 	w, x, y, z int
 	u0, u1 uint
+	i8 int8
+	by byte
+	f64 float64
+	c128 complex128
+	s0 string
+	bs []byte
+	rs []rune
 	xs []int
+	ts []T
+	arr [2]int
 	obj interface{}
+	m map[int]int
+	am map[[2]int]int
+	ch chan int
+	is IntSlice
+	isp *IntSlice
+	tp *T
 )
 
 func f0() int { return 0 }
@@ -38,6 +58,7 @@ func f1(int) int { return 0 }
 func f2(int, int) int { return 0 }
 
 func id(x interface{}) interface{} { return x }
+func v(x int, ys ...int) int { return 0 }
 
 var expr = %s
 `
@@ -59,7 +80,24 @@ var (
 	y = &py.Name{Id: py.Identifier("y")}
 	z = &py.Name{Id: py.Identifier("z")}
 
-	xs = &py.Name{Id: py.Identifier("xs")}
+	i8   = &py.Name{Id: py.Identifier("i8")}
+	by   = &py.Name{Id: py.Identifier("by")}
+	f64  = &py.Name{Id: py.Identifier("f64")}
+	c128 = &py.Name{Id: py.Identifier("c128")}
+
+	s0 = &py.Name{Id: py.Identifier("s0")}
+	bs = &py.Name{Id: py.Identifier("bs")}
+	rs = &py.Name{Id: py.Identifier("rs")}
+
+	is  = &py.Name{Id: py.Identifier("is1")} // is is a Python keyword
+	isp = &py.Name{Id: py.Identifier("isp")}
+	tp  = &py.Name{Id: py.Identifier("tp")}
+
+	runtimeInt = &py.Attribute{Value: runtimeModule, Attr: py.Identifier("int")}
+
+	xs  = &py.Name{Id: py.Identifier("xs")}
+	ts  = &py.Name{Id: py.Identifier("ts")}
+	arr = &py.Name{Id: py.Identifier("arr")}
 
 	T  = &py.Name{Id: py.Identifier("T")}
 	t0 = &py.Name{Id: py.Identifier("t0")}
@@ -69,8 +107,53 @@ var (
 
 	obj = &py.Name{Id: py.Identifier("obj")}
 	m   = &py.Name{Id: py.Identifier("m")}
+	am  = &py.Name{Id: py.Identifier("am")}
+	ch  = &py.Name{Id: py.Identifier("ch")}
+	tch = &py.Name{Id: py.Identifier("tch")}
+	v   = &py.Name{Id: py.Identifier("v")}
+
+	nilSlice = &py.Attribute{Value: runtimeModule, Attr: py.Identifier("nilSlice")}
 )
 
+// copyLambda copies an element of a slice of T
+var copyLambda = &py.Lambda{
+	Args: py.Arguments{Args: []py.Arg{{Arg: py.Identifier("e")}}},
+	Body: copyOf(&py.Name{Id: py.Identifier("e")}),
+}
+
+var copyElem = py.Identifier("copyElem")
+
+func newSlice(args ...py.Expr) py.Expr {
+	return &py.Call{Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("Slice")}, Args: args}
+}
+
+func wrapInt(name string, value py.Expr) py.Expr {
+	return &py.Call{Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier(name)}, Args: []py.Expr{value}}
+}
+
+func callRuntime(name string, args ...py.Expr) py.Expr {
+	return &py.Call{Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier(name)}, Args: args}
+}
+
+func iface(typ, value py.Expr) py.Expr {
+	return callRuntime("Iface", typ, value)
+}
+
+func copyOf(value py.Expr) py.Expr {
+	return &py.Call{Func: &py.Attribute{Value: value, Attr: py.Identifier("__copy__")}}
+}
+
+func intMethod(typ, name string, args ...py.Expr) py.Expr {
+	return &py.Call{
+		Func: &py.Attribute{Value: &py.Attribute{Value: runtimeModule, Attr: py.Identifier(typ)}, Attr: py.Identifier(name)},
+		Args: args,
+	}
+}
+
+func sliceCall(x py.Expr, args ...py.Expr) py.Expr {
+	return &py.Call{Func: &py.Attribute{Value: x, Attr: py.Identifier("slice")}, Args: args}
+}
+
 var exprTests = []struct {
 	golang string
 	python py.Expr
@@ -81,11 +164,20 @@ var exprTests = []struct {
 	// Predeclared identifiers
 	{"true", &py.NameConstant{Value: py.True}},
 	{"false", &py.NameConstant{Value: py.False}},
+	{"id(x)", &py.Call{Func: &py.Name{Id: py.Identifier("id")}, Args: []py.Expr{x}}},
+	{"id(i8)", &py.Call{Func: &py.Name{Id: py.Identifier("id")}, Args: []py.Expr{iface(&py.Attribute{Value: runtimeModule, Attr: py.Identifier("int8")}, i8)}}},
+	{"id(t0)", &py.Call{Func: &py.Name{Id: py.Identifier("id")}, Args: []py.Expr{iface(T, copyOf(t0))}}},
+	{"id(&t0)", &py.Call{Func: &py.Name{Id: py.Identifier("id")}, Args: []py.Expr{t0}}},
+	{"id(tp)", &py.Call{Func: &py.Name{Id: py.Identifier("id")}, Args: []py.Expr{callRuntime("pointerIface", tp, callRuntime("pointerType", T))}}},
+	{"id(xs)", &py.Call{Func: &py.Name{Id: py.Identifier("id")}, Args: []py.Expr{iface(callRuntime("sliceType", runtimeInt), xs)}}},
+	{"id(ch)", &py.Call{Func: &py.Name{Id: py.Identifier("id")}, Args: []py.Expr{iface(callRuntime("chanType", runtimeInt), ch)}}},
+	{"id(is)", &py.Call{Func: &py.Name{Id: py.Identifier("id")}, Args: []py.Expr{iface(&py.Name{Id: py.Identifier("IntSlice")}, is)}}},
+	{"id(f1)", &py.Call{Func: &py.Name{Id: py.Identifier("id")}, Args: []py.Expr{iface(callRuntime("funcType", &py.Tuple{Elts: []py.Expr{runtimeInt}}, &py.Tuple{Elts: []py.Expr{runtimeInt}}), f1)}}},
 	{"id(nil)", &py.Call{Func: &py.Name{Id: py.Identifier("id")}, Args: []py.Expr{&py.NameConstant{Value: py.None}}}},
 
 	// Integer literals
 	{"42", &py.Num{N: "42"}},
-	//{"0600", &py.Num{N: "0o600"}},
+	{"0600", &py.Num{N: "384"}},
 	{"0xBadFace", &py.Num{N: "0xBadFace"}},
 	//{"170141183460469231731687303715884105727", &py.Num{N: "170141183460469231731687303715884105727"}},
 
@@ -120,34 +212,37 @@ var exprTests = []struct {
 	{`"\t"`, &py.Str{S: `"\t"`}},
 	{`"\000"`, &py.Str{S: `"\000"`}},
 	{`"\007"`, &py.Str{S: `"\007"`}},
-	{`"\377"`, &py.Str{S: `"\377"`}},
+	{`"\377"`, &py.Str{S: `"\udcff"`}},
 	{`"\x07"`, &py.Str{S: `"\x07"`}},
-	{`"\xff"`, &py.Str{S: `"\xff"`}},
+	{`"\xff"`, &py.Str{S: `"\udcff"`}},
 	{`"\u12e4"`, &py.Str{S: `"\u12e4"`}},
 	{`"\U00101234"`, &py.Str{S: `"\U00101234"`}},
 	{`"\""`, &py.Str{S: `"\""`}},
 
 	// Rune literals
-	{`'a'`, &py.Str{S: `'a'`}},
-	{`'ä'`, &py.Str{S: `'ä'`}},
-	{`'本'`, &py.Str{S: `'本'`}},
-	{`'\t'`, &py.Str{S: `'\t'`}},
-	{`'\000'`, &py.Str{S: `'\000'`}},
-	{`'\007'`, &py.Str{S: `'\007'`}},
-	{`'\377'`, &py.Str{S: `'\377'`}},
-	{`'\x07'`, &py.Str{S: `'\x07'`}},
-	{`'\xff'`, &py.Str{S: `'\xff'`}},
-	{`'\u12e4'`, &py.Str{S: `'\u12e4'`}},
-	{`'\U00101234'`, &py.Str{S: `'\U00101234'`}},
-	{`'\''`, &py.Str{S: `'\''`}},
+	{`'a'`, &py.Call{Func: pyOrd, Args: []py.Expr{&py.Str{S: `'a'`}}}},
+	{`'ä'`, &py.Call{Func: pyOrd, Args: []py.Expr{&py.Str{S: `'ä'`}}}},
+	{`'本'`, &py.Call{Func: pyOrd, Args: []py.Expr{&py.Str{S: `'本'`}}}},
+	{`'\t'`, &py.Call{Func: pyOrd, Args: []py.Expr{&py.Str{S: `'\t'`}}}},
+	{`'\000'`, &py.Call{Func: pyOrd, Args: []py.Expr{&py.Str{S: `'\000'`}}}},
+	{`'\007'`, &py.Call{Func: pyOrd, Args: []py.Expr{&py.Str{S: `'\007'`}}}},
+	{`'\377'`, &py.Call{Func: pyOrd, Args: []py.Expr{&py.Str{S: `'\377'`}}}},
+	{`'\x07'`, &py.Call{Func: pyOrd, Args: []py.Expr{&py.Str{S: `'\x07'`}}}},
+	{`'\xff'`, &py.Call{Func: pyOrd, Args: []py.Expr{&py.Str{S: `'\xff'`}}}},
+	{`'\u12e4'`, &py.Call{Func: pyOrd, Args: []py.Expr{&py.Str{S: `'\u12e4'`}}}},
+	{`'\U00101234'`, &py.Call{Func: pyOrd, Args: []py.Expr{&py.Str{S: `'\U00101234'`}}}},
+	{`'\''`, &py.Call{Func: pyOrd, Args: []py.Expr{&py.Str{S: `'\''`}}}},
+	{"'a' + 1", &py.Num{N: "98"}},
 
 	// Composite literals
 	{"T{}", &py.Call{Func: T}},
 	{"T{x, y}", &py.Call{Func: T, Args: []py.Expr{x, y}}},
 	{"T{x: y}", &py.Call{Func: T, Keywords: []py.Keyword{py.Keyword{Arg: &x.Id, Value: y}}}},
-	{"[2]T{t0, t1}", &py.List{Elts: []py.Expr{t0, t1}}},
-	{"[...]T{t0, t1}", &py.List{Elts: []py.Expr{t0, t1}}},
-	{"[]T{t0, t1}", &py.List{Elts: []py.Expr{t0, t1}}},
+	{"[2]T{t0, t1}", &py.List{Elts: []py.Expr{copyOf(t0), copyOf(t1)}}},
+	{"[...]T{t0, t1}", &py.List{Elts: []py.Expr{copyOf(t0), copyOf(t1)}}},
+	{"[2]T{{}, T{}}", &py.List{Elts: []py.Expr{&py.Call{Func: T}, &py.Call{Func: T}}}},
+	{"[3]int{x}", &py.List{Elts: []py.Expr{x, zero, zero}}},
+	{"[]T{t0, t1}", newSlice(&py.List{Elts: []py.Expr{copyOf(t0), copyOf(t1)}})},
 	{"map[T]U{}", &py.Dict{
 		Keys:   []py.Expr{},
 		Values: []py.Expr{},
@@ -156,12 +251,19 @@ var exprTests = []struct {
 		Keys:   []py.Expr{x, z},
 		Values: []py.Expr{y, w},
 	}},
-	{"[]T{{x, y},{z, w}}", &py.List{
+	{"[]T{{x, y},{z, w}}", newSlice(&py.List{
 		Elts: []py.Expr{
 			&py.Call{Func: T, Args: []py.Expr{x, y}},
 			&py.Call{Func: T, Args: []py.Expr{z, w}},
 		},
-	}},
+	})},
+	{"[]*T{{x, y}}", newSlice(&py.List{
+		Elts: []py.Expr{
+			&py.Call{Func: T, Args: []py.Expr{x, y}},
+		},
+	})},
+	{"[]interface{}{nil, x}", newSlice(&py.List{Elts: []py.Expr{pyNone, x}})},
+	{"[][]int{nil}", newSlice(&py.List{Elts: []py.Expr{nilSlice}})},
 	{"map[T]U{{x, y}: {}, {z, w}: {}}", &py.Dict{
 		Keys: []py.Expr{
 			&py.Call{Func: T, Args: []py.Expr{x, y}},
@@ -182,17 +284,60 @@ var exprTests = []struct {
 	{"x >= y", &py.Compare{Left: x, Comparators: []py.Expr{y}, Ops: []py.CmpOp{py.GtE}}},
 
 	// Arithmetic operators
-	{"x + y", &py.BinOp{Left: x, Right: y, Op: py.Add}},
-	{"x - y", &py.BinOp{Left: x, Right: y, Op: py.Sub}},
-	{"x * y", &py.BinOp{Left: x, Right: y, Op: py.Mult}},
-	{"x / y", &py.BinOp{Left: x, Right: y, Op: py.FloorDiv}}, //TODO FloorDiv if ints, Div if floats
-	{"x % y", &py.BinOp{Left: x, Right: y, Op: py.Mod}},
+	{"x + y", wrapInt("int", &py.BinOp{Left: x, Right: y, Op: py.Add})},
+	{"x - y", wrapInt("int", &py.BinOp{Left: x, Right: y, Op: py.Sub})},
+	{"x * y", wrapInt("int", &py.BinOp{Left: x, Right: y, Op: py.Mult})},
+	{"x / y", intMethod("int", "quo", x, y)},
+	{"x % y", intMethod("int", "rem", x, y)},
 	{"x & y", &py.BinOp{Left: x, Right: y, Op: py.BitAnd}},
 	{"x | y", &py.BinOp{Left: x, Right: y, Op: py.BitOr}},
 	{"x ^ y", &py.BinOp{Left: x, Right: y, Op: py.BitXor}},
-	{"x << u0", &py.BinOp{Left: x, Right: u0, Op: py.LShift}},
+	{"x << u0", wrapInt("int", &py.BinOp{Left: x, Right: u0, Op: py.LShift})},
 	{"x >> u0", &py.BinOp{Left: x, Right: u0, Op: py.RShift}},
 	{"x &^ y", &py.BinOp{Left: x, Right: &py.UnaryOpExpr{Operand: y, Op: py.Invert}, Op: py.BitAnd}},
+	{"u0 + u1", wrapInt("uint", &py.BinOp{Left: u0, Right: u1, Op: py.Add})},
+	{"i8 * i8", wrapInt("int8", &py.BinOp{Left: i8, Right: i8, Op: py.Mult})},
+	{"by + 1", wrapInt("uint8", &py.BinOp{Left: by, Right: one, Op: py.Add})},
+	{"f64 * f64", &py.BinOp{Left: f64, Right: f64, Op: py.Mult}},
+
+	// Division
+	{"i8 / i8", intMethod("int8", "quo", i8, i8)},
+	{"u0 / u1", &py.BinOp{Left: u0, Right: u1, Op: py.FloorDiv}},
+	{"u0 % u1", &py.BinOp{Left: u0, Right: u1, Op: py.Mod}},
+	{"f64 / f64", &py.BinOp{Left: f64, Right: f64, Op: py.Div}},
+	{"f64 / 2", &py.BinOp{Left: f64, Right: &py.Num{N: "2.0"}, Op: py.Div}},
+	{"-7 / 2", &py.Num{N: "-3"}},
+	{"-7 % 2", &py.Num{N: "-1"}},
+	{"7.0 / 2", &py.Num{N: "3.5"}},
+
+	// Constant expressions are folded
+	{"1 + 2", &py.Num{N: "3"}},
+	{"1 << 70 >> 68", &py.Num{N: "4"}},
+	{"^uint8(3)", &py.Num{N: "252"}},
+	{"float64(7)", &py.Num{N: "7.0"}},
+	{"complex128(7)", &py.Call{Func: pyComplex, Args: []py.Expr{&py.Num{N: "7.0"}, &py.Num{N: "0.0"}}}},
+
+	// Integer conversions
+	{"uint8(x)", wrapInt("uint8", x)},
+	{"int64(by)", wrapInt("int64", by)},
+	{"int8(1)", one},
+
+	// Other conversions
+	{"int(f64)", intMethod("int", "trunc", f64)},
+	{"float32(f64)", callRuntime("float32", f64)},
+	{"float64(f64)", f64},
+	{"float64(x)", callRuntime("float64", x)},
+	{"float64(1)", &py.Num{N: "1.0"}},
+	{"float32(0.1)", &py.Num{N: "0.10000000149011612"}},
+	{"float64(float32(0.1))", &py.Num{N: "0.10000000149011612"}},
+	{"string(rune(x))", callRuntime("runeToString", wrapInt("int32", x))},
+	{"string(bs)", callRuntime("bytesToString", bs)},
+	{"string(rs)", callRuntime("runesToString", rs)},
+	{"[]byte(s0)", callRuntime("stringToBytes", s0)},
+	{"[]rune(s0)", callRuntime("stringToRunes", s0)},
+	{"[2]int(xs)", callRuntime("sliceToArray", xs, two)},
+	{"string('a')", &py.Str{S: `"a"`}},
+	{"IntSlice(xs)", xs},
 
 	// Logical operators
 	{"b0 && b1", &py.BoolOpExpr{Values: []py.Expr{b0, b1}, Op: py.And}},
@@ -207,9 +352,12 @@ var exprTests = []struct {
 	{"(x)", x},
 
 	// Unary operators
-	{"-x", &py.UnaryOpExpr{Operand: x, Op: py.USub}},
+	{"-x", wrapInt("int", &py.UnaryOpExpr{Operand: x, Op: py.USub})},
+	{"-1", &py.Num{N: "-1"}},
 	{"+x", &py.UnaryOpExpr{Operand: x, Op: py.UAdd}},
-	{"^x", &py.UnaryOpExpr{Operand: x, Op: py.Invert}}, // TODO incorrect for unsigned
+	{"^x", &py.UnaryOpExpr{Operand: x, Op: py.Invert}},
+	{"^u0", wrapInt("uint", &py.UnaryOpExpr{Operand: u0, Op: py.Invert})},
+	{"<-ch", &py.Call{Func: &py.Attribute{Value: ch, Attr: py.Identifier("recv")}}},
 
 	// Selector
 	{"T{}.y", &py.Attribute{
@@ -227,15 +375,25 @@ var exprTests = []struct {
 
 	// Index
 	{"xs[y]", &py.Subscript{Value: xs, Slice: &py.Index{Value: y}}},
+	{"m[y]", callRuntime("mapIndex", m, y, zero)},
+	{"am[arr]", callRuntime("mapIndex", am, callRuntime("arrayKey", arr), zero)},
+	{"map[[2]int]int{arr: 1}", &py.Dict{Keys: []py.Expr{callRuntime("arrayKey", arr)}, Values: []py.Expr{one}}},
 
 	// Slice
-	{"xs[y:z]", &py.Subscript{Value: xs, Slice: &py.RangeSlice{Lower: y, Upper: z}}},
-	{"xs[y:]", &py.Subscript{Value: xs, Slice: &py.RangeSlice{Lower: y}}},
-	{"xs[:z]", &py.Subscript{Value: xs, Slice: &py.RangeSlice{Upper: z}}},
-	{"xs[:]", &py.Subscript{Value: xs, Slice: &py.RangeSlice{}}},
+	{"xs[y:z]", sliceCall(xs, y, z)},
+	{"xs[y:]", sliceCall(xs, y)},
+	{"xs[:z]", sliceCall(xs, zero, z)},
+	{"xs[:]", sliceCall(xs)},
+	{"xs[y:z:w]", sliceCall(xs, y, z, w)},
+	{"xs[:z:w]", sliceCall(xs, zero, z, w)},
+	{"arr[y:]", sliceCall(newSlice(arr), y)},
+	{`"abc"[y:z]`, callRuntime("sliceString", &py.Str{S: `"abc"`}, y, z)},
+	{"s0[:z]", callRuntime("sliceString", s0, pyNone, z)},
+	{"s0 + s0", callRuntime("concat", s0, s0)},
+	{"s0[y]", &py.Subscript{Value: callRuntime("encode", s0), Slice: &py.Index{Value: y}}},
 
 	// Built-in functions
-	{"make([]T, x)", &py.ListComp{
+	{"make([]T, x)", newSlice(&py.ListComp{
 		Elt: &py.Call{Func: T},
 		Generators: []py.Comprehension{
 			py.Comprehension{
@@ -243,8 +401,8 @@ var exprTests = []struct {
 				Iter: &py.Call{
 					Func: pyRange,
 					Args: []py.Expr{x}},
-			}}}},
-	{"make(IntSlice, x)", &py.ListComp{
+			}}})},
+	{"make(IntSlice, x)", newSlice(&py.ListComp{
 		Elt: zero,
 		Generators: []py.Comprehension{
 			py.Comprehension{
@@ -252,37 +410,94 @@ var exprTests = []struct {
 				Iter: &py.Call{
 					Func: pyRange,
 					Args: []py.Expr{x}},
-			}}}},
-	{"make([]T, x, y)", &py.ListComp{
+			}}})},
+	{"make([]T, x, y)", newSlice(&py.ListComp{
 		Elt: &py.Call{Func: T},
 		Generators: []py.Comprehension{
 			py.Comprehension{
 				Target: &py.Name{Id: py.Identifier("_")},
 				Iter: &py.Call{
 					Func: pyRange,
-					Args: []py.Expr{x}},
-			}}}},
+					Args: []py.Expr{y}},
+			}}}, zero, x)},
 	{"make(map[T]U)", &py.Dict{}},
+	{"make(chan int)", &py.Call{
+		Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("Chan")},
+		Args: []py.Expr{zero, zero}}},
+	{"make(chan T, x)", &py.Call{
+		Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("Chan")},
+		Args: []py.Expr{x, &py.Call{Func: T}}}},
+	{"make(chan struct{})", &py.Call{
+		Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("Chan")},
+		Args: []py.Expr{zero, pyNone}}},
+	{"struct{}{}", pyNone},
+	{"len(ch)", callRuntime("length", ch)},
+	{"len(m)", callRuntime("length", m)},
+	{"cap(ch)", &py.Call{
+		Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("cap")},
+		Args: []py.Expr{ch}}},
 	{"len(xs)", &py.Call{Func: pyLen, Args: []py.Expr{xs}}},
-	{`len("")`, &py.Call{
-		Func: pyLen,
-		Args: []py.Expr{
-			&py.Call{
-				Func: &py.Attribute{Value: pyEmptyString, Attr: py.Identifier("encode")},
-				Args: []py.Expr{&py.Str{S: `"utf-8"`}},
-			},
-		},
-	}},
-	{"cap(xs)", &py.Call{Func: pyLen, Args: []py.Expr{xs}}},
+	{"len(s0)", &py.Call{Func: pyLen, Args: []py.Expr{callRuntime("encode", s0)}}},
+	{`len("abc")`, &py.Num{N: "3"}},
+	{"cap(xs)", &py.Call{
+		Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("cap")},
+		Args: []py.Expr{xs}}},
+	{"cap(arr)", two},
+	{"append(xs, x, y)", &py.Call{
+		Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("append")},
+		Args: []py.Expr{xs, zero, x, y}}},
+	{"append(xs, xs...)", &py.Call{
+		Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("appendSlice")},
+		Args: []py.Expr{xs, xs, zero}}},
+	{"append(ts, t0)", &py.Call{
+		Func:     &py.Attribute{Value: runtimeModule, Attr: py.Identifier("append")},
+		Args:     []py.Expr{ts, &py.Call{Func: T}, copyOf(t0)},
+		Keywords: []py.Keyword{{Arg: &copyElem, Value: copyLambda}}}},
+	{"append(ts, ts...)", &py.Call{
+		Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("appendSlice")},
+		Args: []py.Expr{ts, ts, &py.Call{Func: T}, copyLambda}}},
+	{"copy(xs, xs)", &py.Call{
+		Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("copy")},
+		Args: []py.Expr{xs, xs}}},
+	{"xs == nil", &py.Compare{Left: xs, Ops: []py.CmpOp{py.Is}, Comparators: []py.Expr{nilSlice}}},
+	{"nil != obj", &py.Compare{Left: obj, Ops: []py.CmpOp{py.IsNot}, Comparators: []py.Expr{pyNone}}},
+	{"v(x)", &py.Call{Func: v, Args: []py.Expr{x, nilSlice}}},
+	{"v(x, y, z)", &py.Call{Func: v, Args: []py.Expr{x, newSlice(&py.List{Elts: []py.Expr{y, z}})}}},
+	{"v(x, xs...)", &py.Call{Func: v, Args: []py.Expr{x, xs}}},
+	{"recover()", &py.Call{Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("recover")}}},
 	{"new(T)", &py.Call{Func: T}},
-	{"new(int)", &py.Num{N: "0"}},
+	{"new(int)", callRuntime("Box", &py.Num{N: "0"})},
+
+	// Interfaces
+	{"obj.(int)", callRuntime("typeAssert", obj, runtimeInt)},
+	{"obj.(T)", callRuntime("typeAssert", obj, T)},
+	{"obj.(*T)", callRuntime("typeAssert", obj, callRuntime("pointerType", T))},
+	{"obj.(error)", callRuntime("typeAssert", obj, &py.Attribute{Value: runtimeModule, Attr: py.Identifier("error")})},
+	{"obj.(interface{ Area() int })", callRuntime("typeAssert", obj, callRuntime("interfaceType", &py.Str{S: `"Area"`}))},
+	{"obj == i8", &py.Compare{Left: obj, Ops: []py.CmpOp{py.Eq}, Comparators: []py.Expr{iface(&py.Attribute{Value: runtimeModule, Attr: py.Identifier("int8")}, i8)}}},
+	{"x != obj", &py.Compare{Left: x, Ops: []py.CmpOp{py.NotEq}, Comparators: []py.Expr{obj}}},
+	{"Lener(is)", iface(&py.Name{Id: py.Identifier("IntSlice")}, is)},
+	{"is.Len()", &py.Call{Func: &py.Attribute{Value: &py.Name{Id: py.Identifier("IntSlice")}, Attr: py.Identifier("Len")}, Args: []py.Expr{is}}},
+	{"is.Push(x)", &py.Call{Func: &py.Attribute{Value: &py.Name{Id: py.Identifier("IntSlice")}, Attr: py.Identifier("Push")}, Args: []py.Expr{is, x}}},
+	{"isp.Len()", &py.Call{
+		Func: &py.Attribute{Value: &py.Name{Id: py.Identifier("IntSlice")}, Attr: py.Identifier("Len")},
+		Args: []py.Expr{&py.Attribute{Value: isp, Attr: py.Identifier("value")}},
+	}},
+
+	// Pointers
+	{"&x", x},
+	{"*&x", &py.Attribute{Value: x, Attr: py.Identifier("value")}},
+	{"&t0", t0},
+	{"*&t0", t0},
+	{"&t0.x", callRuntime("FieldPointer", t0, &py.Str{S: `"x"`})},
+	{"&xs[y]", callRuntime("IndexPointer", xs, y)},
+	{"&[]int{}", callRuntime("Box", newSlice(&py.List{Elts: []py.Expr{}}))},
+	{"&t0 == &t1", &py.Compare{Left: t0, Ops: []py.CmpOp{py.Is}, Comparators: []py.Expr{t1}}},
 	{"complex(1.0, 2.0)", &py.Call{Func: pyComplex, Args: []py.Expr{&py.Num{N: "1.0"}, &py.Num{N: "2.0"}}}},
-	{"real(1+2i)", &py.Attribute{
-		Attr:  py.Identifier("real"),
-		Value: &py.BinOp{Left: &py.Num{N: "1"}, Op: py.Add, Right: &py.Num{N: "2j"}}}},
-	{"imag(1+2i)", &py.Attribute{
-		Attr:  py.Identifier("imag"),
-		Value: &py.BinOp{Left: &py.Num{N: "1"}, Op: py.Add, Right: &py.Num{N: "2j"}}}},
+	{"real(c128)", &py.Attribute{Attr: py.Identifier("real"), Value: c128}},
+	{"imag(c128)", &py.Attribute{Attr: py.Identifier("imag"), Value: c128}},
+	{"real(1+2i)", &py.Num{N: "1.0"}},
+	{"imag(1+2i)", &py.Num{N: "2.0"}},
 }
 
 var sp = spew.NewDefaultConfig()
@@ -337,3 +552,75 @@ func TestExpr(t *testing.T) {
 		})
 	}
 }
+
+// Name of temp variable used to store an operand
+var tmp = &py.Name{Id: py.Identifier("t")}
+
+// hoistedExprTests compile the operands of a binary expression, hoisting
+// the statement f0() while compiling the second operand.
+var hoistedExprTests = []struct {
+	golang string
+	value  py.Expr
+	stmts  []py.Stmt
+}{
+	// Operands that call functions are evaluated before the statement
+	{"f1(0) + x", runtimeCall("int", &py.BinOp{Left: tmp, Op: py.Add, Right: x}), []py.Stmt{
+		&py.Assign{Targets: []py.Expr{tmp}, Value: &py.Call{Func: f1, Args: []py.Expr{zero}}},
+		&py.ExprStmt{Value: &py.Call{Func: f0}},
+	}},
+	{"w + x", runtimeCall("int", &py.BinOp{Left: w, Op: py.Add, Right: x}), []py.Stmt{
+		&py.ExprStmt{Value: &py.Call{Func: f0}},
+	}},
+	// The statement runs only if the first operand does not decide the result
+	{"b0 && b1", tmp, []py.Stmt{
+		&py.Assign{Targets: []py.Expr{tmp}, Value: b0},
+		&py.If{Test: tmp, Body: []py.Stmt{
+			&py.ExprStmt{Value: &py.Call{Func: f0}},
+			&py.Assign{Targets: []py.Expr{tmp}, Value: b1},
+		}},
+	}},
+	{"b0 || b1", tmp, []py.Stmt{
+		&py.Assign{Targets: []py.Expr{tmp}, Value: b0},
+		&py.If{Test: &py.UnaryOpExpr{Op: py.Not, Operand: tmp}, Body: []py.Stmt{
+			&py.ExprStmt{Value: &py.Call{Func: f0}},
+			&py.Assign{Targets: []py.Expr{tmp}, Value: b1},
+		}},
+	}},
+}
+
+func TestHoistedExpr(t *testing.T) {
+	for _, test := range hoistedExprTests {
+		t.Run(test.golang, func(t *testing.T) {
+			pkg, file, errs := buildFile(fmt.Sprintf(exprPkgTemplate, test.golang))
+			if errs != nil {
+				t.Errorf("failed to build Go expr %q", test.golang)
+				for _, e := range errs {
+					t.Error(e)
+				}
+				t.FailNow()
+			}
+
+			c := NewCompiler(&pkg.Info, nil).exprCompiler()
+			goExpr := file.Scope.Lookup("expr").Decl.(*ast.ValueSpec).Values[0].(*ast.BinaryExpr)
+			compile := func(i int, expr ast.Expr) py.Expr {
+				if i == 1 {
+					c.addStmt(&py.ExprStmt{Value: &py.Call{Func: f0}})
+				}
+				return c.compileExpr(expr)
+			}
+			var value py.Expr
+			if op, ok := boolOp(goExpr.Op); ok {
+				x := compile(0, goExpr.X)
+				mark := len(c.stmts)
+				value = c.shortCircuit(op, x, mark, compile(1, goExpr.Y))
+			} else {
+				operands := c.compileInOrder([]ast.Expr{goExpr.X, goExpr.Y}, compile)
+				value = c.arith(goExpr.Op, c.TypeOf(goExpr), operands[0], operands[1])
+			}
+			if !reflect.DeepEqual(value, test.value) || !reflect.DeepEqual(c.stmts, test.stmts) {
+				t.Errorf("\nwant %s\n%s\ngot  %s\n%s", pythonExprCode(test.value), pythonCode(test.stmts),
+					pythonExprCode(value), pythonCode(c.stmts))
+			}
+		})
+	}
+}
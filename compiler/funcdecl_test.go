@@ -26,6 +26,7 @@ var (
 	xs []int
 	obj interface{}
 	m map[int]int
+	seq func(func(int) bool)
 )
 
 func ignore(interface{}) {}
@@ -42,6 +43,11 @@ func s(...interface{}) {}
 
 var noClass py.Identifier
 
+var (
+	i      = &py.Name{Id: py.Identifier("i")}
+	iValue = &py.Attribute{Value: i, Attr: py.Identifier("value")}
+)
+
 var funcDeclTests = []struct {
 	golang string
 	python FuncDecl
@@ -81,6 +87,19 @@ var funcDeclTests = []struct {
 			},
 		},
 	}}},
+	{"func (x T) f() { x.y = 0 }", FuncDecl{T.Id, &py.FunctionDef{
+		// A value receiver is copied if it is modified
+		Name: f,
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{x}, Value: copyOf(x)},
+			&py.Assign{Targets: []py.Expr{&py.Attribute{Value: x, Attr: y.Id}}, Value: &py.Num{N: "0"}},
+		},
+		Args: py.Arguments{
+			Args: []py.Arg{
+				py.Arg{Arg: x.Id},
+			},
+		},
+	}}},
 	{"func (T) f() {s(0)}", FuncDecl{T.Id, &py.FunctionDef{
 		Name: f,
 		Body: s(0),
@@ -91,6 +110,21 @@ var funcDeclTests = []struct {
 		},
 	}}},
 
+	// Parameters whose address is taken are boxed
+	{"func f(x int) *int { return &x }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Args: py.Arguments{
+			Args: []py.Arg{py.Arg{Arg: x.Id}},
+		},
+		Body: []py.Stmt{
+			&py.Assign{
+				Targets: []py.Expr{x},
+				Value:   &py.Call{Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("Box")}, Args: []py.Expr{x}},
+			},
+			&py.Return{Value: x},
+		},
+	}}},
+
 	// Return
 	{"func f() { return }", FuncDecl{noClass, &py.FunctionDef{
 		Name: f,
@@ -98,14 +132,198 @@ var funcDeclTests = []struct {
 			&py.Return{},
 		},
 	}}},
-	// TODO named return values
-	// {"func f() (x int) { return }", FuncDecl{noClass, &py.FunctionDef{
-	// 	Name: f,
-	// 	Body: []py.Stmt{
-	// 		&py.Assign{Targets: []py.Expr{x}, Value: &py.Num{N: "0"}},
-	// 		&py.Return{Value: x},
-	// 	},
-	// }}},
+	{"func f() (x int) { return }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{x}, Value: zero},
+			&py.Return{Value: x},
+		},
+	}}},
+	{"func f() (x int, _ bool) { return 1, true }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{x, &py.Name{Id: py.Identifier("_")}}, Value: &py.Tuple{Elts: []py.Expr{zero, pyFalse}}},
+			&py.Return{Value: &py.Tuple{Elts: []py.Expr{one, pyTrue}}},
+		},
+	}}},
+	{"func f() (x int) { _ = &x; return }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{x}, Value: callRuntime("Box", zero)},
+			&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("_")}}, Value: x},
+			&py.Return{Value: &py.Attribute{Value: x, Attr: py.Identifier("value")}},
+		},
+	}}},
+	{"func f() (x int) { func() { x = 1 }(); return }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{x}, Value: zero},
+			&py.FunctionDef{
+				Name: py.Identifier("func"),
+				Body: []py.Stmt{
+					&py.Nonlocal{Names: []py.Identifier{x.Id}},
+					&py.Assign{Targets: []py.Expr{x}, Value: one},
+				},
+			},
+			&py.ExprStmt{Value: &py.Call{Func: &py.Name{Id: py.Identifier("func")}}},
+			&py.Return{Value: x},
+		},
+	}}},
+
+	// Assignments to variables of the package and enclosing functions
+	{"func f() { x = 1; y++; z := 0; _ = z }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.Global{Names: []py.Identifier{x.Id, y.Id}},
+			&py.Assign{Targets: []py.Expr{x}, Value: one},
+			&py.Assign{Targets: []py.Expr{y}, Value: callRuntime("int", &py.BinOp{Left: y, Op: py.Add, Right: one})},
+			&py.Assign{Targets: []py.Expr{z}, Value: zero},
+			&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("_")}}, Value: z},
+		},
+	}}},
+	{"func f(z int) { func() { z, x = 1, z }() }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Args: py.Arguments{Args: []py.Arg{py.Arg{Arg: z.Id}}},
+		Body: []py.Stmt{
+			&py.FunctionDef{
+				Name: py.Identifier("func"),
+				Body: []py.Stmt{
+					&py.Global{Names: []py.Identifier{x.Id}},
+					&py.Nonlocal{Names: []py.Identifier{z.Id}},
+					&py.Assign{Targets: []py.Expr{z, x}, Value: &py.Tuple{Elts: []py.Expr{one, z}}},
+				},
+			},
+			&py.ExprStmt{Value: &py.Call{Func: &py.Name{Id: py.Identifier("func")}}},
+		},
+	}}},
+	{"func f(z int) { _ = &z; func() { z = 1 }() }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Args: py.Arguments{Args: []py.Arg{py.Arg{Arg: z.Id}}},
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{z}, Value: callRuntime("Box", z)},
+			&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("_")}}, Value: z},
+			&py.FunctionDef{
+				Name: py.Identifier("func"),
+				Body: []py.Stmt{
+					&py.Assign{Targets: []py.Expr{&py.Attribute{Value: z, Attr: py.Identifier("value")}}, Value: one},
+				},
+			},
+			&py.ExprStmt{Value: &py.Call{Func: &py.Name{Id: py.Identifier("func")}}},
+		},
+	}}},
+
+	// Function literals bind the loop variables of the current iteration
+	{"func f() { for i := range xs { _ = func() int { return i } } }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.For{
+				Target: i,
+				Iter:   &py.Call{Func: pyRange, Args: []py.Expr{&py.Call{Func: pyLen, Args: []py.Expr{xs}}}},
+				Body: []py.Stmt{
+					&py.FunctionDef{
+						Name: py.Identifier("func"),
+						Args: py.Arguments{Kwonlyargs: []py.Arg{{Arg: i.Id}}, KwDefaults: []py.Expr{i}},
+						Body: []py.Stmt{&py.Return{Value: i}},
+					},
+					&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("_")}}, Value: &py.Name{Id: py.Identifier("func")}},
+				},
+			},
+		},
+	}}},
+	{"func f() { for i := 0; i < 1; i++ { _ = func() { i++ } } }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{i}, Value: callRuntime("Box", zero)},
+			&py.While{
+				Test: &py.Compare{Left: iValue, Ops: []py.CmpOp{py.Lt}, Comparators: []py.Expr{one}},
+				Body: []py.Stmt{
+					&py.FunctionDef{
+						Name: py.Identifier("func"),
+						Args: py.Arguments{Kwonlyargs: []py.Arg{{Arg: i.Id}}, KwDefaults: []py.Expr{i}},
+						Body: []py.Stmt{
+							&py.Assign{Targets: []py.Expr{iValue}, Value: callRuntime("int", &py.BinOp{Left: iValue, Op: py.Add, Right: one})},
+						},
+					},
+					&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("_")}}, Value: &py.Name{Id: py.Identifier("func")}},
+					&py.Assign{Targets: []py.Expr{i}, Value: callRuntime("Box", iValue)},
+					&py.Assign{Targets: []py.Expr{iValue}, Value: callRuntime("int", &py.BinOp{Left: iValue, Op: py.Add, Right: one})},
+				},
+			},
+		},
+	}}},
+	{"func f() { for { z := 0; _ = func() int { return z } } }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.While{
+				Test: pyTrue,
+				Body: []py.Stmt{
+					&py.Assign{Targets: []py.Expr{z}, Value: zero},
+					&py.FunctionDef{
+						Name: py.Identifier("func"),
+						Args: py.Arguments{Kwonlyargs: []py.Arg{{Arg: z.Id}}, KwDefaults: []py.Expr{z}},
+						Body: []py.Stmt{&py.Return{Value: z}},
+					},
+					&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("_")}}, Value: &py.Name{Id: py.Identifier("func")}},
+				},
+			},
+		},
+	}}},
+
+	// The bound of a counting loop is evaluated once if it cannot change
+	{"func f(z int) { for i := 0; i < z; i++ {} }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Args: py.Arguments{Args: []py.Arg{{Arg: z.Id}}},
+		Body: []py.Stmt{
+			&py.For{Target: i, Iter: &py.Call{Func: pyRange, Args: []py.Expr{z}}, Body: []py.Stmt{&py.Pass{}}},
+		},
+	}}},
+	{"func f(z int) { for i := 0; i < z; i++ { z = 0 } }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Args: py.Arguments{Args: []py.Arg{{Arg: z.Id}}},
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{i}, Value: zero},
+			&py.While{
+				Test: &py.Compare{Left: i, Ops: []py.CmpOp{py.Lt}, Comparators: []py.Expr{z}},
+				Body: []py.Stmt{
+					&py.Assign{Targets: []py.Expr{z}, Value: zero},
+					&py.Assign{Targets: []py.Expr{i}, Value: callRuntime("int", &py.BinOp{Left: i, Op: py.Add, Right: one})},
+				},
+			},
+		},
+	}}},
+
+	// Go blocks share the Python function scope
+	{"func f() { x := 0; { x := x; _ = x }; _ = x }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{x}, Value: zero},
+			&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("x1")}}, Value: x},
+			&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("_")}}, Value: &py.Name{Id: py.Identifier("x1")}},
+			&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("_")}}, Value: x},
+		},
+	}}},
+	{"func f() { _ = y; y := 0; _ = y }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("_")}}, Value: y},
+			&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("y1")}}, Value: zero},
+			&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("_")}}, Value: &py.Name{Id: py.Identifier("y1")}},
+		},
+	}}},
+	{"func f(len, None int) { _ = len + None }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Args: py.Arguments{Args: []py.Arg{{Arg: py.Identifier("len1")}, {Arg: py.Identifier("None1")}}},
+		Body: []py.Stmt{
+			&py.Assign{
+				Targets: []py.Expr{&py.Name{Id: py.Identifier("_")}},
+				Value: callRuntime("int", &py.BinOp{
+					Left:  &py.Name{Id: py.Identifier("len1")},
+					Op:    py.Add,
+					Right: &py.Name{Id: py.Identifier("None1")},
+				}),
+			},
+		},
+	}}},
 
 	// Test that identifiers that hide builtins can be called
 	{"func make(); func f() { make() }", FuncDecl{noClass, &py.FunctionDef{
@@ -198,24 +416,286 @@ var funcDeclTests = []struct {
 			&py.Try{
 				Body: []py.Stmt{
 					&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("x")}}, Value: one},
-					&py.ExprStmt{&py.Call{
+					&py.ExprStmt{Value: &py.Call{
 						Func: &py.Attribute{Value: &py.Name{Id: py.Identifier("defers")}, Attr: py.Identifier("append")},
 						Args: []py.Expr{&py.Tuple{Elts: []py.Expr{&py.Name{Id: py.Identifier("ignore")}, &py.Tuple{Elts: []py.Expr{&py.Name{Id: py.Identifier("x")}}}}}},
 					}},
 					&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("_")}}, Value: &py.Name{Id: py.Identifier("x")}},
 				},
-				Finalbody: []py.Stmt{
-					&py.For{
-						Target: &py.Tuple{Elts: []py.Expr{&py.Name{Id: "fun"}, &py.Name{Id: "args"}}},
-						Iter:   &py.Call{Func: pyReversed, Args: []py.Expr{&py.Name{Id: py.Identifier("defers")}}},
-						Body:   []py.Stmt{&py.ExprStmt{&py.Call{Func: &py.Name{Id: "fun"}, Args: []py.Expr{&py.Starred{Value: &py.Name{Id: "args"}}}}}},
+				Handlers: []py.ExceptHandler{
+					py.ExceptHandler{
+						Typ:  pyException,
+						Name: py.Identifier("exc"),
+						Body: []py.Stmt{runDefers(&py.Name{Id: py.Identifier("exc")})},
+					},
+				},
+				Finalbody: []py.Stmt{runDefers()},
+			},
+		},
+	}}},
+	{"func f(c chan int) { defer close(c) }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Args: py.Arguments{Args: []py.Arg{{Arg: py.Identifier("c")}}},
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("defers")}}, Value: &py.List{}},
+			&py.Try{
+				Body: []py.Stmt{
+					&py.ExprStmt{Value: &py.Call{
+						Func: &py.Attribute{Value: &py.Name{Id: py.Identifier("defers")}, Attr: py.Identifier("append")},
+						Args: []py.Expr{&py.Tuple{Elts: []py.Expr{
+							&py.Attribute{Value: &py.Name{Id: py.Identifier("c")}, Attr: py.Identifier("close")},
+							&py.Tuple{},
+						}}},
+					}},
+				},
+				Handlers: []py.ExceptHandler{
+					py.ExceptHandler{
+						Typ:  pyException,
+						Name: py.Identifier("exc"),
+						Body: []py.Stmt{runDefers(&py.Name{Id: py.Identifier("exc")})},
+					},
+				},
+				Finalbody: []py.Stmt{runDefers()},
+			},
+		},
+	}}},
+	{"func f() { defer delete(m, x) }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("defers")}}, Value: &py.List{}},
+			&py.Try{
+				Body: []py.Stmt{
+					&py.ExprStmt{Value: &py.Call{
+						Func: &py.Attribute{Value: &py.Name{Id: py.Identifier("defers")}, Attr: py.Identifier("append")},
+						Args: []py.Expr{&py.Tuple{Elts: []py.Expr{
+							&py.Attribute{Value: runtimeModule, Attr: py.Identifier("delete")},
+							&py.Tuple{Elts: []py.Expr{m, x}},
+						}}},
+					}},
+				},
+				Handlers: []py.ExceptHandler{
+					py.ExceptHandler{
+						Typ:  pyException,
+						Name: py.Identifier("exc"),
+						Body: []py.Stmt{runDefers(&py.Name{Id: py.Identifier("exc")})},
+					},
+				},
+				Finalbody: []py.Stmt{runDefers()},
+			},
+		},
+	}}},
+	{"func f() int { defer recover(); return 1 }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("defers")}}, Value: &py.List{}},
+			&py.Try{
+				Body: []py.Stmt{
+					&py.ExprStmt{Value: &py.Call{
+						Func: &py.Attribute{Value: &py.Name{Id: py.Identifier("defers")}, Attr: py.Identifier("append")},
+						Args: []py.Expr{&py.Tuple{Elts: []py.Expr{
+							&py.Attribute{Value: runtimeModule, Attr: py.Identifier("recover")},
+							&py.Tuple{},
+						}}},
+					}},
+					&py.Return{Value: one},
+				},
+				Handlers: []py.ExceptHandler{
+					py.ExceptHandler{
+						Typ:  pyException,
+						Name: py.Identifier("exc"),
+						Body: []py.Stmt{
+							runDefers(&py.Name{Id: py.Identifier("exc")}),
+							&py.Return{Value: zero},
+						},
+					},
+				},
+				Finalbody: []py.Stmt{runDefers()},
+			},
+		},
+	}}},
+	{"func f() (x int) { defer ignore(x); return 1 }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("defers")}}, Value: &py.List{}},
+			&py.Try{
+				Body: []py.Stmt{
+					&py.Assign{Targets: []py.Expr{x}, Value: zero},
+					deferIgnore(x),
+					&py.Assign{Targets: []py.Expr{x}, Value: one},
+				},
+				Handlers: []py.ExceptHandler{
+					py.ExceptHandler{
+						Typ:  pyException,
+						Name: py.Identifier("exc"),
+						Body: []py.Stmt{runDefers(&py.Name{Id: py.Identifier("exc")})},
+					},
+				},
+				Finalbody: []py.Stmt{runDefers()},
+			},
+			&py.Return{Value: x},
+		},
+	}}},
+	{"func f() (x int) { defer ignore(x); for { return 1 } }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("defers")}}, Value: &py.List{}},
+			&py.Try{
+				Body: []py.Stmt{
+					&py.Assign{Targets: []py.Expr{x}, Value: zero},
+					&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("returned")}}, Value: pyFalse},
+					&py.While{Test: pyTrue, Body: []py.Stmt{
+						deferIgnore(x),
+						&py.While{Test: pyTrue, Body: []py.Stmt{
+							&py.Assign{Targets: []py.Expr{x}, Value: one},
+							&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("returned")}}, Value: pyTrue},
+							&py.Break{},
+						}},
+						&py.If{Test: &py.Name{Id: py.Identifier("returned")}, Body: []py.Stmt{&py.Break{}}},
+						&py.Break{},
+					}},
+				},
+				Handlers: []py.ExceptHandler{
+					py.ExceptHandler{
+						Typ:  pyException,
+						Name: py.Identifier("exc"),
+						Body: []py.Stmt{runDefers(&py.Name{Id: py.Identifier("exc")})},
+					},
+				},
+				Finalbody: []py.Stmt{runDefers()},
+			},
+			&py.Return{Value: x},
+		},
+	}}},
+	// Returning from the body of a range-over-func loop
+	{"func f() int { for x := range seq { return x }; return 0 }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("returned")}}, Value: pyFalse},
+			&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("result")}}, Value: pyNone},
+			&py.FunctionDef{
+				Name: py.Identifier("body"),
+				Args: py.Arguments{Args: []py.Arg{{Arg: py.Identifier("x")}}},
+				Body: []py.Stmt{
+					&py.Nonlocal{Names: []py.Identifier{"result", "returned"}},
+					&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("result")}}, Value: x},
+					&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("returned")}}, Value: pyTrue},
+					&py.Return{Value: pyFalse},
+				},
+			},
+			&py.ExprStmt{Value: runtimeCall("rangeFunc", seq, &py.Name{Id: py.Identifier("body")})},
+			&py.If{
+				Test: &py.Name{Id: py.Identifier("returned")},
+				Body: []py.Stmt{&py.Return{Value: &py.Name{Id: py.Identifier("result")}}},
+			},
+			&py.Return{Value: zero},
+		},
+	}}},
+}
+
+// Tests of loop variables, which iterations share before Go 1.22
+var goVersionFuncDeclTests = []struct {
+	goVersion string
+	golang    string
+	python    FuncDecl
+}{
+	{"go1.21", "func f() { for i := range xs { _ = func() int { return i } } }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.For{
+				Target: i,
+				Iter:   &py.Call{Func: pyRange, Args: []py.Expr{&py.Call{Func: pyLen, Args: []py.Expr{xs}}}},
+				Body: []py.Stmt{
+					&py.FunctionDef{
+						Name: py.Identifier("func"),
+						Body: []py.Stmt{&py.Return{Value: i}},
 					},
+					&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("_")}}, Value: &py.Name{Id: py.Identifier("func")}},
+				},
+			},
+		},
+	}}},
+	{"go1.21", "func f() { for i := range xs { _ = &i } }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{i}, Value: callRuntime("Box", zero)},
+			&py.For{
+				Target: iValue,
+				Iter:   &py.Call{Func: pyRange, Args: []py.Expr{&py.Call{Func: pyLen, Args: []py.Expr{xs}}}},
+				Body:   []py.Stmt{&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("_")}}, Value: i}},
+			},
+		},
+	}}},
+	{"go1.22", "func f() { for i := range xs { _ = &i } }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.For{
+				Target: i,
+				Iter:   &py.Call{Func: pyRange, Args: []py.Expr{&py.Call{Func: pyLen, Args: []py.Expr{xs}}}},
+				Body: []py.Stmt{
+					&py.Assign{Targets: []py.Expr{i}, Value: callRuntime("Box", i)},
+					&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("_")}}, Value: i},
+				},
+			},
+		},
+	}}},
+	{"go1.21", "func f() { for i := 0; i < 1; i++ { _ = &i } }", FuncDecl{noClass, &py.FunctionDef{
+		Name: f,
+		Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{i}, Value: callRuntime("Box", zero)},
+			&py.While{
+				Test: &py.Compare{Left: iValue, Ops: []py.CmpOp{py.Lt}, Comparators: []py.Expr{one}},
+				Body: []py.Stmt{
+					&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("_")}}, Value: i},
+					&py.Assign{Targets: []py.Expr{iValue}, Value: callRuntime("int", &py.BinOp{Left: iValue, Op: py.Add, Right: one})},
 				},
 			},
 		},
 	}}},
 }
 
+func TestFuncDeclGoVersion(t *testing.T) {
+	for _, test := range goVersionFuncDeclTests {
+		t.Run(test.goVersion+" "+test.golang, func(t *testing.T) {
+			pkg, file, errs := buildFile(fmt.Sprintf(funcDeclPkgTemplate, test.golang))
+			if errs != nil {
+				t.Errorf("failed to build Go func decl %q", test.golang)
+				for _, e := range errs {
+					t.Error(e)
+				}
+				t.FailNow()
+			}
+
+			c := NewCompiler(&pkg.Info, nil)
+			c.GoVersion = test.goVersion
+
+			goFuncDecl := file.Decls[len(file.Decls)-1].(*ast.FuncDecl)
+			pyFuncDecl := c.compileFuncDecl(goFuncDecl)
+			if !reflect.DeepEqual(pyFuncDecl, test.python) {
+				t.Errorf("%q\nwant:\n%s\ngot:\n%s\n", test.golang,
+					pythonCode([]py.Stmt{test.python.Def}), pythonCode([]py.Stmt{pyFuncDecl.Def}))
+			}
+		})
+	}
+}
+
+// deferIgnore is the statement that defers a call to ignore(arg).
+func deferIgnore(arg py.Expr) py.Stmt {
+	return &py.ExprStmt{Value: &py.Call{
+		Func: &py.Attribute{Value: &py.Name{Id: py.Identifier("defers")}, Attr: py.Identifier("append")},
+		Args: []py.Expr{&py.Tuple{Elts: []py.Expr{&py.Name{Id: py.Identifier("ignore")}, &py.Tuple{Elts: []py.Expr{arg}}}}},
+	}}
+}
+
+// runDefers is the statement that runs the deferred calls of a function,
+// passing the exception unwinding the function if any.
+func runDefers(exc ...py.Expr) py.Stmt {
+	args := append([]py.Expr{&py.Name{Id: py.Identifier("defers")}}, exc...)
+	return &py.ExprStmt{Value: &py.Call{
+		Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("runDefers")},
+		Args: args,
+	}}
+}
+
 func TestFuncDecl(t *testing.T) {
 	for _, test := range funcDeclTests {
 		t.Run(test.golang, func(t *testing.T) {
@@ -0,0 +1,198 @@
+package compiler
+
+import (
+	"bytes"
+	py "github.com/mbergin/gotopython/pythonast"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// Each of these programs is compiled and run with python3, and must print
+// the given output, which is what it prints when run with Go.
+var runTests = []struct {
+	name   string
+	golang string
+	output string
+}{
+	{"print", `package main
+func main() {
+	var f32 float32 = 0.1
+	var err error
+	var m map[string]int
+	println(1, true, "héllo", 2.5, 1e21, f32, 1+2i, err, m)
+	print("a", 1, "\n")
+}
+`, "1 true héllo 2.5 1e+21 0.1 (1+2i) (0x0,0x0) 0x0\na1\n"},
+
+	{"constants", `package main
+type Color int
+const (
+	Red Color = iota
+	Green
+	Blue
+)
+func main() {
+	var f float64 = 7
+	println(Red, Green, Blue, f/2, ^uint8(3), 1<<70>>68)
+}
+`, "0 1 2 3.5 252 4\n"},
+
+	{"strings", `package main
+func main() {
+	s := "héllo"
+	println(len(s), s[1], s[2], s[1:3] == "é", s[:1]+s[3:])
+	for i, r := range s {
+		print(i, ":", r, " ")
+	}
+	println()
+}
+`, "6 195 169 true hllo\n0:104 1:233 3:108 4:108 5:111 \n"},
+
+	{"slices", `package main
+type T struct{ x int }
+func main() {
+	var xs []int
+	for i := 0; i < 3; i++ {
+		xs = append(xs, i)
+	}
+	ys := xs[:cap(xs)]
+	println(len(xs), ys[len(ys)-1])
+	ts := append([]T(nil), T{1})
+	ts = append(ts, ts...)
+	us := ts[:cap(ts)]
+	us[0].x = 2
+	println(ts[0].x, ts[1].x)
+}
+`, "3 0\n2 1\n"},
+
+	{"maps", `package main
+func main() {
+	var m map[string]int
+	v, ok := m["k"]
+	delete(m, "k")
+	println(len(m), v, ok)
+	n := map[[2]int]string{{1, 2}: "a"}
+	n[[2]int{3, 4}] = "b"
+	delete(n, [2]int{1, 2})
+	for k, v := range n {
+		println(k[0], k[1], v)
+	}
+}
+`, "0 0 false\n3 4 b\n"},
+
+	{"panic and recover", `package main
+type ParseError string
+func (e ParseError) Error() string { return string(e) }
+func parse() (err error) {
+	defer func() {
+		if e, ok := recover().(ParseError); ok {
+			err = e
+		}
+	}()
+	panic(ParseError("bad input"))
+}
+func main() {
+	println(parse().Error())
+}
+`, "bad input\n"},
+
+	{"type switch", `package main
+type I interface{ from() int }
+type T struct{ n int }
+func (t *T) from() int { return t.n }
+func describe(x interface{}) {
+	switch v := x.(type) {
+	case nil:
+		println("nil")
+	case int, string:
+		println("int or string")
+	case I:
+		println("I", v.from())
+	default:
+		println("other")
+	}
+}
+func main() {
+	describe(nil)
+	describe(1)
+	describe(&T{3})
+	describe(T{4})
+}
+`, "nil\nint or string\nI 3\nother\n"},
+
+	{"channels", `package main
+func main() {
+	ch := make(chan int)
+	done := make(chan struct{})
+	go func() {
+		for v := range ch {
+			println("got", v)
+		}
+		close(done)
+	}()
+	for i := 0; i < 2; i++ {
+		select {
+		case ch <- i:
+		}
+	}
+	close(ch)
+	<-done
+	var nilCh chan int
+	select {
+	case <-nilCh:
+		println("unreachable")
+	default:
+		println("default")
+	}
+}
+`, "got 0\ngot 1\ndefault\n"},
+}
+
+func TestRun(t *testing.T) {
+	python, err := exec.LookPath("python3")
+	if err != nil {
+		t.Skip("python3 not found")
+	}
+	for _, test := range runTests {
+		t.Run(test.name, func(t *testing.T) {
+			pkg, _, errs := buildFile(test.golang)
+			if errs != nil {
+				t.Errorf("failed to build Go package %q", test.golang)
+				for _, e := range errs {
+					t.Error(e)
+				}
+				t.FailNow()
+			}
+
+			c := NewCompiler(&pkg.Info, nil)
+			var code bytes.Buffer
+			py.NewWriter(&code).WriteModule(c.CompileFiles(pkg.Files))
+			dir, err := ioutil.TempDir("", "gotopython")
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer os.RemoveAll(dir)
+			main := filepath.Join(dir, "main.py")
+			if err := ioutil.WriteFile(main, code.Bytes(), 0666); err != nil {
+				t.Fatal(err)
+			}
+			if err := ioutil.WriteFile(filepath.Join(dir, "runtime.py"), []byte(RuntimeSource), 0666); err != nil {
+				t.Fatal(err)
+			}
+
+			// print and println write to standard error, as in Go
+			var output bytes.Buffer
+			cmd := exec.Command(python, main)
+			cmd.Stderr = &output
+			if err := cmd.Run(); err != nil {
+				t.Fatalf("%s: %v\n%s\n%s", test.name, err, output.String(), code.String())
+			}
+			if got := output.String(); got != test.output {
+				t.Errorf("%s\nwant:\n%s\ngot:\n%s\n%s", test.name, test.output, got, code.String())
+			}
+		})
+	}
+}
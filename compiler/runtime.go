@@ -1,7 +1,38 @@
 package compiler
 
-import py "github.com/mbergin/gotopython/pythonast"
+import (
+	_ "embed" // for RuntimeSource
+	py "github.com/mbergin/gotopython/pythonast"
+	"strconv"
+)
+
+// RuntimeVersion is the version of the runtime module that generated code requires.
+// It must match VERSION in runtime.py.
+const RuntimeVersion = 1
+
+// RuntimeSource is the source of the Python runtime module that every generated
+// module imports. It must be written alongside the generated module as runtime.py.
+//
+//go:embed runtime.py
+var RuntimeSource string
 
 var (
 	runtimeModule = &py.Name{Id: py.Identifier("runtime")}
 )
+
+func runtimeAttr(name string) py.Expr {
+	return &py.Attribute{Value: runtimeModule, Attr: py.Identifier(name)}
+}
+
+func runtimeCall(name string, args ...py.Expr) *py.Call {
+	return &py.Call{Func: runtimeAttr(name), Args: args}
+}
+
+// runtimeImport imports the runtime module and checks that it is the version
+// this compiler generates code for.
+func runtimeImport() []py.Stmt {
+	return []py.Stmt{
+		&py.Import{Names: []py.Alias{py.Alias{Name: runtimeModule.Id}}},
+		&py.ExprStmt{Value: runtimeCall("checkVersion", &py.Num{N: strconv.Itoa(RuntimeVersion)})},
+	}
+}
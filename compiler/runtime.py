@@ -0,0 +1,1342 @@
+"""Runtime support for Python modules generated by gotopython.
+
+Every generated module imports this module as ``runtime``. It is the single
+home for the parts of Go's semantics that have no direct Python equivalent,
+such as the type descriptors used by type switches and conversions.
+
+This file is embedded in the gotopython binary and written alongside the
+generated module. Generated code checks VERSION on import so that a module is
+never run against a runtime it was not compiled for.
+"""
+
+import builtins
+import collections
+import functools
+import math
+import os
+import random
+import struct
+import sys
+import threading
+import traceback
+import typing
+
+VERSION = 1
+
+
+def checkVersion(version):
+    if version != VERSION:
+        raise ImportError(
+            "module requires gotopython runtime version %d but found version %d"
+            % (version, VERSION))
+
+
+# All goroutine scheduling state is guarded by _sched. Blocked goroutines wait
+# on it and are woken by whichever goroutine makes them runnable.
+_sched = threading.Condition()
+
+# The number of goroutines, and how many of them are blocked on channel
+# operations. _live is zero unless the program was started by main, in which
+# case all goroutines are known and deadlock can be detected.
+_live = 0
+_blocked = 0
+
+
+class _Waiter:
+    """A goroutine parked until another goroutine wakes it."""
+
+    def __init__(self):
+        self.woken = False
+        self.sudog = None
+
+
+def _park(waiter):
+    # Must be called with _sched held.
+    global _blocked
+    _blocked += 1
+    _checkDeadlock()
+    while not waiter.woken:
+        _sched.wait()
+
+
+def _wake(waiter):
+    # Must be called with _sched held.
+    global _blocked
+    if not waiter.woken:
+        waiter.woken = True
+        _blocked -= 1
+        _sched.notify_all()
+
+
+def _checkDeadlock():
+    if _live and _blocked == _live:
+        sys.stderr.write("fatal error: all goroutines are asleep - deadlock!\n")
+        _exit(2)
+
+
+def go(f, *args):
+    """Run f(*args) on a new goroutine.
+
+    Goroutines are daemon threads so that, as in Go, they do not keep the
+    program alive once main returns.
+    """
+    global _live
+    with _sched:
+        if _live:
+            _live += 1
+    threading.Thread(target=_goroutine, args=(f, args), daemon=True).start()
+
+
+def _goroutine(f, args):
+    global _live
+    try:
+        f(*args)
+    except BaseException as e:
+        _crash(e)
+    with _sched:
+        if _live:
+            _live -= 1
+            _checkDeadlock()
+
+
+def main(f):
+    """Run the main function of a program and exit when it returns."""
+    global _live
+    with _sched:
+        _live = 1
+    try:
+        f()
+    except BaseException as e:
+        _crash(e)
+    _exit(0)
+
+
+_crashLock = threading.Lock()
+
+
+def _crash(e):
+    # An unrecovered panic on any goroutine terminates the whole program.
+    # Only the first goroutine to crash reports its panic.
+    _crashLock.acquire()
+    sys.stderr.write("panic: %s\n\n" % (_panicMessage(e),))
+    traceback.print_exception(builtins.type(e), e, e.__traceback__)
+    _exit(2)
+
+
+def _exit(code):
+    sys.stdout.flush()
+    sys.stderr.flush()
+    # os._exit rather than sys.exit so that running goroutines are not waited for.
+    os._exit(code)
+
+
+class GoPanic(Exception):
+    """A Go panic carrying the value passed to panic."""
+
+    def __init__(self, value):
+        Exception.__init__(self, value)
+        self.value = value
+
+    def __str__(self):
+        return _panicMessage(self)
+
+
+def panic(value):
+    raise GoPanic(value)
+
+
+class _runtimeError:
+    """A run-time panic such as an out of range index, raised by Python as an
+    exception other than GoPanic."""
+
+    def __init__(self, msg):
+        self.msg = msg
+
+    def Error(self):
+        return "runtime error: " + self.msg
+
+    def RuntimeError(self):
+        pass
+
+    def __str__(self):
+        return self.Error()
+
+
+def panicValue(e):
+    """The value that recover returns for the exception e."""
+    if isinstance(e, GoPanic):
+        return e.value
+    if isinstance(e, ZeroDivisionError):
+        return _runtimeError("integer divide by zero")
+    if isinstance(e, IndexError):
+        return _runtimeError("index out of range")
+    if isinstance(e, TypeError) and "'NoneType' object does not support item assignment" in str(e):
+        # Storing an entry in a nil map, which is None
+        return _plainError("assignment to entry in nil map")
+    if isinstance(e, (AttributeError, TypeError)) and "NoneType" in str(e):
+        return _runtimeError("invalid memory address or nil pointer dereference")
+    return _runtimeError("%s: %s" % (builtins.type(e).__name__, e))
+
+
+def _panicMessage(e):
+    value = panicValue(e)
+    if hasattr(value, "Error"):
+        return value.Error()
+    if hasattr(value, "String"):
+        return value.String()
+    if isinstance(value, builtins.bool):
+        return "true" if value else "false"
+    return str(value)
+
+
+# Each goroutine has a stack of the functions that are running their deferred
+# calls, innermost last.
+_deferring = threading.local()
+
+
+class _DeferFrame:
+    def __init__(self, exc):
+        # The exception unwinding the function, or None if it returned normally
+        # or the panic was recovered.
+        self.exc = exc
+
+
+def _deferFrames():
+    try:
+        return _deferring.frames
+    except AttributeError:
+        _deferring.frames = []
+        return _deferring.frames
+
+
+def runDefers(defers, exc=None):
+    """Run a function's deferred calls, most recent first.
+
+    exc is the exception unwinding the function, if any. It is re-raised once
+    the deferred calls have run unless one of them recovers it. A panic in a
+    deferred call replaces the one in progress.
+    """
+    frames = _deferFrames()
+    frame = _DeferFrame(exc)
+    frames.append(frame)
+    try:
+        while defers:
+            f, args = defers.pop()
+            try:
+                f(*args)
+            except Exception as e:
+                frame.exc = e
+    finally:
+        frames.pop()
+    if frame.exc is not None:
+        raise frame.exc
+
+
+def recover():
+    """Stop the panic unwinding the function whose deferred calls are running
+    and return its value, or return None if there is no such panic."""
+    frames = _deferFrames()
+    if not frames or frames[-1].exc is None:
+        return None
+    frame = frames[-1]
+    value = panicValue(frame.exc)
+    frame.exc = None
+    return value
+
+
+class _Sudog:
+    """A goroutine waiting in a channel's send or receive queue.
+
+    A goroutine blocked in select has a sudog in the queue of every channel it
+    waits on, all sharing one waiter. Only the first to be dequeued proceeds.
+    """
+
+    def __init__(self, waiter, value=None, index=0):
+        self.waiter = waiter
+        self.value = value
+        self.index = index
+        self.ok = False
+        self.closed = False
+
+
+def _dequeue(q):
+    # Pop the first sudog whose goroutine has not already been woken by
+    # another channel in a select.
+    while q:
+        sudog = q.popleft()
+        if not sudog.waiter.woken:
+            return sudog
+    return None
+
+
+def _ready(sudog):
+    sudog.waiter.sudog = sudog
+    _wake(sudog.waiter)
+
+
+class Chan:
+    """A Go channel with a buffer of the given size.
+
+    An unbuffered channel has size 0: a send blocks until a receiver takes
+    the value. zero is the value received from a closed channel.
+    """
+
+    def __init__(self, size=0, zero=None):
+        self.size = size
+        self.zero = zero
+        self.closed = False
+        self.buf = collections.deque()
+        self.sendq = collections.deque()
+        self.recvq = collections.deque()
+
+    def __len__(self):
+        with _sched:
+            return len(self.buf)
+
+    def cap(self):
+        return self.size
+
+    def _trySend(self, value):
+        # Must be called with _sched held.
+        if self.closed:
+            raise GoPanic(_plainError("send on closed channel"))
+        receiver = _dequeue(self.recvq)
+        if receiver:
+            receiver.value, receiver.ok = value, True
+            _ready(receiver)
+            return True
+        if len(self.buf) < self.size:
+            self.buf.append(value)
+            return True
+        return False
+
+    def _tryRecv(self):
+        # Must be called with _sched held. Returns whether a value was
+        # received, the value, and whether it was sent rather than the zero
+        # value of a closed channel.
+        if self.buf:
+            value = self.buf.popleft()
+            sender = _dequeue(self.sendq)
+            if sender:
+                # Make room for the first blocked sender.
+                self.buf.append(sender.value)
+                _ready(sender)
+            return True, value, True
+        sender = _dequeue(self.sendq)
+        if sender:
+            _ready(sender)
+            return True, sender.value, True
+        if self.closed:
+            return True, self.zero, False
+        return False, None, False
+
+    def send(self, value):
+        with _sched:
+            if self._trySend(value):
+                return
+            sender = _Sudog(_Waiter(), value)
+            self.sendq.append(sender)
+            _park(sender.waiter)
+            if sender.closed:
+                raise GoPanic(_plainError("send on closed channel"))
+
+    def recv(self):
+        return self.recvOk()[0]
+
+    def recvOk(self):
+        """Receive a value and whether it was sent rather than the zero value
+        of a closed channel."""
+        with _sched:
+            done, value, ok = self._tryRecv()
+            if done:
+                return value, ok
+            receiver = _Sudog(_Waiter())
+            self.recvq.append(receiver)
+            _park(receiver.waiter)
+            return receiver.value, receiver.ok
+
+    def close(self):
+        with _sched:
+            if self.closed:
+                raise GoPanic(_plainError("close of closed channel"))
+            self.closed = True
+            while self.recvq:
+                receiver = _dequeue(self.recvq)
+                if receiver:
+                    receiver.value, receiver.ok = self.zero, False
+                    _ready(receiver)
+            while self.sendq:
+                sender = _dequeue(self.sendq)
+                if sender:
+                    sender.closed = True
+                    _ready(sender)
+
+    def __iter__(self):
+        # for v := range ch receives until the channel is closed.
+        while True:
+            value, ok = self.recvOk()
+            if not ok:
+                return
+            yield value
+
+
+def selectRecv(ch):
+    """A receive case of a select statement."""
+    return (ch, False, None)
+
+
+def selectSend(ch, value):
+    """A send case of a select statement."""
+    return (ch, True, value)
+
+
+def select(cases, block):
+    """Perform a select statement over cases made by selectRecv and selectSend.
+
+    One ready case is chosen pseudo-randomly. If none is ready, select returns
+    immediately when block is false (the select has a default case) and
+    otherwise waits until one is. Cases on nil channels are never ready.
+
+    Returns the index of the chosen case, or -1 for the default case, and for
+    a receive case the value received and whether it was sent.
+    """
+    with _sched:
+        order = builtins.list(builtins.range(len(cases)))
+        random.shuffle(order)
+        for i in order:
+            ch, isSend, value = cases[i]
+            if ch is None:
+                continue
+            if isSend:
+                if ch._trySend(value):
+                    return i, None, False
+            else:
+                done, value, ok = ch._tryRecv()
+                if done:
+                    return i, value, ok
+        if not block:
+            return -1, None, False
+
+        waiter = _Waiter()
+        sudogs = []
+        for i in order:
+            ch, isSend, value = cases[i]
+            if ch is None:
+                continue
+            sudog = _Sudog(waiter, value, i)
+            (ch.sendq if isSend else ch.recvq).append(sudog)
+            sudogs.append((ch, isSend, sudog))
+        _park(waiter)
+        for ch, isSend, sudog in sudogs:
+            q = ch.sendq if isSend else ch.recvq
+            if sudog in q:
+                q.remove(sudog)
+
+        sudog = waiter.sudog
+        if cases[sudog.index][1]:
+            if sudog.closed:
+                raise GoPanic(_plainError("send on closed channel"))
+            return sudog.index, None, False
+        return sudog.index, sudog.value, sudog.ok
+
+
+class _plainError:
+    """A runtime error whose message has no "runtime error: " prefix, such as
+    "send on closed channel"."""
+
+    def __init__(self, msg):
+        self.msg = msg
+
+    def Error(self):
+        return self.msg
+
+    def RuntimeError(self):
+        pass
+
+    def __str__(self):
+        return self.Error()
+
+
+def cap(x):
+    if x is None:
+        # A nil channel
+        return 0
+    return x.cap()
+
+
+def length(x):
+    """len(x) for a map or channel x, which is 0 if x is nil."""
+    if x is None:
+        return 0
+    return len(x)
+
+
+def print(*args):
+    """The built-in print, which writes to standard error."""
+    _print(args, b"", b"")
+
+
+def println(*args):
+    """The built-in println, which separates its arguments with spaces."""
+    _print(args, b" ", b"\n")
+
+
+def _print(args, sep, end):
+    data = sep.join(_printArg(x) for x in args) + end
+    sys.stderr.flush()
+    sys.stderr.buffer.write(data)
+    sys.stderr.buffer.flush()
+
+
+def _printArg(x):
+    if isinstance(x, builtins.str):
+        return encode(x)
+    if isinstance(x, builtins.bool):
+        s = "true" if x else "false"
+    elif isinstance(x, (builtins.int, builtins.float, builtins.complex)):
+        s = _formatNumber(x, 64)
+    elif x is None:
+        s = "0x0"
+    elif isinstance(x, Slice):
+        s = "[%d/%d]0x%x" % (x.length, x.capacity, 0 if x is nilSlice else id(x.array))
+    else:
+        s = "0x%x" % id(x)
+    return s.encode()
+
+
+def printFloat32(x):
+    """The float32 or complex64 x as print writes it."""
+    return _formatNumber(x, 32)
+
+
+def _formatNumber(x, bits):
+    if isinstance(x, builtins.complex):
+        im = _formatFloat(x.imag, bits)
+        if im[0] not in "+-":
+            im = "+" + im
+        return "(%s%si)" % (_formatFloat(x.real, bits), im)
+    if isinstance(x, builtins.float):
+        return _formatFloat(x, bits)
+    return builtins.str(x)
+
+
+def _formatFloat(f, bits):
+    # The shortest decimal that reads back as f, formatted as by
+    # strconv.FormatFloat(f, 'g', -1, bits).
+    if math.isnan(f):
+        return "NaN"
+    if math.isinf(f):
+        return "+Inf" if f > 0 else "-Inf"
+    sign = "-" if math.copysign(1, f) < 0 else ""
+    f = abs(f)
+    if f == 0:
+        return sign + "0"
+    if bits == 32:
+        for precision in builtins.range(9):
+            r = "%.*e" % (precision, f)
+            if _float32(builtins.float(r)) == f:
+                break
+    else:
+        r = "%r" % f
+    # The significant digits, with the decimal point after the first dp
+    mantissa, _, exponent = r.partition("e")
+    whole, _, fraction = mantissa.partition(".")
+    digits = (whole + fraction).lstrip("0")
+    dp = builtins.len(whole) + builtins.int(exponent or "0") - (builtins.len(whole + fraction) - builtins.len(digits))
+    digits = digits.rstrip("0")
+    exp = dp - 1
+    if exp < -4 or exp >= 6:
+        s = digits[0]
+        if builtins.len(digits) > 1:
+            s += "." + digits[1:]
+        return "%s%se%s%02d" % (sign, s, "-" if exp < 0 else "+", abs(exp))
+    if dp <= 0:
+        return sign + "0." + "0" * -dp + digits
+    if dp >= builtins.len(digits):
+        return sign + digits + "0" * (dp - builtins.len(digits))
+    return sign + digits[:dp] + "." + digits[dp:]
+
+
+def printIface(x):
+    """The interface value x as print writes it, a pair of addresses."""
+    if x is None:
+        return "(0x0,0x0)"
+    return "(0x%x,0x%x)" % (id(typeOf(x)), id(valueOf(x)))
+
+
+class Box:
+    """A pointer to a variable whose address is taken. Pointers to structs
+    and arrays are the objects themselves and are not boxed."""
+
+    __slots__ = ("value",)
+
+    def __init__(self, value):
+        self.value = value
+
+
+class FieldPointer:
+    """A pointer to the field name of the struct obj."""
+
+    __slots__ = ("obj", "name")
+
+    def __init__(self, obj, name):
+        if obj is None:
+            raise GoPanic(_runtimeError("invalid memory address or nil pointer dereference"))
+        self.obj = obj
+        self.name = name
+
+    @property
+    def value(self):
+        return getattr(self.obj, self.name)
+
+    @value.setter
+    def value(self, value):
+        setattr(self.obj, self.name, value)
+
+    def __eq__(self, other):
+        return isinstance(other, FieldPointer) and self.obj is other.obj and self.name == other.name
+
+    def __hash__(self):
+        return builtins.hash((builtins.id(self.obj), self.name))
+
+
+class IndexPointer:
+    """A pointer to element index of an array or slice."""
+
+    __slots__ = ("array", "index")
+
+    def __init__(self, seq, index):
+        if isinstance(seq, Slice):
+            self.array = seq.array
+            self.index = seq._index(index)
+        else:
+            if not 0 <= index < len(seq):
+                raise GoPanic(_runtimeError(
+                    "index out of range [%d] with length %d" % (index, len(seq))))
+            self.array = seq
+            self.index = index
+
+    @property
+    def value(self):
+        return self.array[self.index]
+
+    @value.setter
+    def value(self, value):
+        self.array[self.index] = value
+
+    def __eq__(self, other):
+        return isinstance(other, IndexPointer) and self.array is other.array and self.index == other.index
+
+    def __hash__(self):
+        return builtins.hash((builtins.id(self.array), self.index))
+
+
+def store(p, value):
+    """*p = value, where p points to a struct or array. The object is updated
+    in place because other pointers to it may exist."""
+    if isinstance(p, builtins.list):
+        p[:] = value
+    else:
+        p.__dict__.update(value.__dict__)
+
+
+class Slice:
+    """A Go slice: a view of length elements of the list array, starting at
+    offset, that can grow in place up to capacity elements.
+
+    Slices of the same array share its elements, so assigning to an element
+    of one is visible through the others.
+    """
+
+    __slots__ = ("array", "offset", "length", "capacity")
+
+    def __init__(self, array, offset=0, length=None, capacity=None):
+        self.array = array
+        self.offset = offset
+        self.length = len(array) - offset if length is None else length
+        self.capacity = len(array) - offset if capacity is None else capacity
+
+    def __len__(self):
+        return self.length
+
+    def cap(self):
+        return self.capacity
+
+    def _index(self, i):
+        if not 0 <= i < self.length:
+            raise GoPanic(_runtimeError(
+                "index out of range [%d] with length %d" % (i, self.length)))
+        return self.offset + i
+
+    def __getitem__(self, i):
+        return self.array[self._index(i)]
+
+    def __setitem__(self, i, value):
+        self.array[self._index(i)] = value
+
+    def __iter__(self):
+        # As in Go, the length is fixed when iteration starts.
+        array, offset = self.array, self.offset
+        for i in builtins.range(self.length):
+            yield array[offset + i]
+
+    def slice(self, low=0, high=None, max=None):
+        """The slice expression s[low:high:max]."""
+        if high is None:
+            high = self.length
+        if max is None:
+            max = self.capacity
+        if not 0 <= low <= high <= max <= self.capacity:
+            raise GoPanic(_runtimeError(
+                "slice bounds out of range [%d:%d:%d] with capacity %d"
+                % (low, high, max, self.capacity)))
+        if self is nilSlice:
+            return self
+        return Slice(self.array, self.offset + low, high - low, max - low)
+
+    def _append(self, elems, zero, copyElem):
+        n = len(elems)
+        if n == 0:
+            return self
+        length = self.length + n
+        start = self.offset + self.length
+        if length <= self.capacity:
+            self.array[start:start + n] = elems
+            return Slice(self.array, self.offset, length, self.capacity)
+        # Grow into a new array, whose elements beyond the new length are
+        # zero values, visible by reslicing beyond len.
+        capacity = builtins.max(length, 2 * self.capacity)
+        array = self.array[self.offset:start]
+        array.extend(elems)
+        if copyElem:
+            array.extend(copyElem(zero) for _ in builtins.range(capacity - length))
+        else:
+            array.extend([zero] * (capacity - length))
+        return Slice(array, 0, length, capacity)
+
+    def __str__(self):
+        return "[%s]" % " ".join(str(x) for x in self)
+
+    __repr__ = __str__
+
+
+# The nil slice, which is the zero value of every slice type.
+nilSlice = Slice([])
+
+
+def append(s, zero, *elems, copyElem=None):
+    """append(s, elems...), where zero is the zero value of the elements.
+
+    copyElem copies elements that are structs or arrays.
+    """
+    return s._append(elems, zero, copyElem)
+
+
+def appendSlice(s, t, zero, copyElem=None):
+    """append(s, t...), where t is a slice or, for a byte slice s, a string,
+    and zero is the zero value of the elements.
+
+    copyElem copies elements that are structs or arrays.
+    """
+    if isinstance(t, str):
+        t = encode(t)
+    if copyElem:
+        return s._append([copyElem(e) for e in t], zero, copyElem)
+    return s._append(builtins.list(t), zero, copyElem)
+
+
+def copy(dst, src, copyElem=None):
+    """Copy elements from src, a slice or a string, to the slice dst and
+    return the number copied. The slices may overlap.
+
+    copyElem copies elements that are structs or arrays.
+    """
+    if isinstance(src, str):
+        src = encode(src)
+    n = builtins.min(len(dst), len(src))
+    elems = [src[i] for i in builtins.range(n)]
+    if copyElem:
+        elems = [copyElem(e) for e in elems]
+    for i in builtins.range(n):
+        dst[i] = elems[i]
+    return n
+
+
+# A Go string is a Python str. Bytes that are not valid UTF-8 are decoded to
+# the surrogate escapes U+DC80 to U+DCFF, so that converting a string to bytes
+# and back gives the original bytes, as in Go.
+
+
+def encode(s):
+    """The bytes of the string s."""
+    return s.encode("utf-8", "surrogateescape")
+
+
+def sliceString(s, low, high):
+    """s[low:high] for a string s, which slices the bytes of s."""
+    return encode(s)[low:high].decode("utf-8", "surrogateescape")
+
+
+def concat(a, b):
+    """a + b for strings a and b."""
+    if a and b and "\udc80" <= a[-1] <= "\udcff" and "\udc80" <= b[0] <= "\udcff":
+        # The escaped bytes at the join may form a character
+        return (encode(a) + encode(b)).decode("utf-8", "surrogateescape")
+    return a + b
+
+
+def _rune(c):
+    # The rune of a character of a string, which is U+FFFD for an invalid byte.
+    r = ord(c)
+    return 0xFFFD if 0xDC80 <= r <= 0xDCFF else r
+
+
+def stringToBytes(s):
+    """[]byte(s)"""
+    return Slice(builtins.list(encode(s)))
+
+
+def stringToRunes(s):
+    """[]rune(s)"""
+    return Slice([_rune(c) for c in s])
+
+
+def bytesToString(b):
+    """string(b) for a byte slice b."""
+    return builtins.bytes(b).decode("utf-8", "surrogateescape")
+
+
+def runesToString(r):
+    """string(r) for a rune slice r."""
+    return "".join(runeToString(c) for c in r)
+
+
+def runeToString(r):
+    """string(r) for an integer r: the UTF-8 encoding of r, or of U+FFFD if r
+    is not a valid Unicode code point."""
+    if 0 <= r <= 0x10FFFF and not 0xD800 <= r <= 0xDFFF:
+        return chr(r)
+    return "\uFFFD"
+
+
+def sliceToArray(s, n, copyElem=None):
+    """[n]T(s), a copy of the first n elements of the slice s, which must have
+    at least n elements.
+
+    copyElem copies elements that are structs or arrays.
+    """
+    if len(s) < n:
+        raise GoPanic(_runtimeError(
+            "cannot convert slice with length %d to array or pointer to array with length %d" % (len(s), n)))
+    elems = [s[i] for i in builtins.range(n)]
+    if copyElem:
+        elems = [copyElem(e) for e in elems]
+    return elems
+
+
+def mapIndex(m, key, zero):
+    """m[key], or zero if the map m is nil or has no entry for key."""
+    if m is None:
+        return zero
+    return m.get(key, zero)
+
+
+def mapIndexOk(m, key, zero, copyElem=None):
+    """m[key] and whether the map m has an entry for key, as v, ok := m[key]
+    assigns them. The value is zero if there is no entry.
+
+    copyElem copies elements that are structs or arrays.
+    """
+    if m is None or key not in m:
+        return zero, False
+    value = m[key]
+    if copyElem:
+        value = copyElem(value)
+    return value, True
+
+
+def delete(m, key):
+    """delete(m, key), which does nothing if the map m is nil or has no entry
+    for key."""
+    if m is not None:
+        m.pop(key, None)
+
+
+def rangeString(s):
+    """The byte offsets and runes of the string s, as a range loop over it
+    produces them."""
+    i = 0
+    for c in s:
+        yield i, _rune(c)
+        i += len(encode(c))
+
+
+def rangeMap(m, arrayKeys=False):
+    """The keys and values of the map m, as a range loop over it produces
+    them. The loop may delete entries, which are not produced if they have
+    not been reached.
+
+    arrayKeys is set if the keys are arrays, which are stored as tuples.
+    """
+    if m is None:
+        return
+    for k in builtins.list(m):
+        if k in m:
+            yield (_keyArray(k) if arrayKeys else k), m[k]
+
+
+def rangeFunc(seq, body):
+    """Run a range-over-func loop: call the iterator seq with a yield
+    function that runs body, the loop body, which returns False to end the
+    loop."""
+    done = False
+
+    def yield_(*args):
+        nonlocal done
+        if done:
+            raise GoPanic(_runtimeError(
+                "range function continued iteration after function for loop body returned false"))
+        done = not body(*args)
+        return not done
+
+    seq(yield_)
+
+
+class Type:
+    """A Go type descriptor.
+
+    Descriptors compare equal when they describe identical Go types, so
+    sliceType(int) == sliceType(int).
+    """
+
+    def _key(self):
+        raise NotImplementedError
+
+    def __eq__(self, other):
+        return builtins.type(self) is builtins.type(other) and self._key() == other._key()
+
+    def __ne__(self, other):
+        return not self == other
+
+    def __hash__(self):
+        return hash((builtins.type(self), self._key()))
+
+
+class BasicType(Type):
+    """A predeclared Go type such as int, float64 or string."""
+
+    def __init__(self, name):
+        self.name = name
+
+    def _key(self):
+        return self.name
+
+    def __repr__(self):
+        return self.name
+
+
+class IntType(BasicType):
+    """A Go integer type. Calling it wraps an int to the range of the type, as
+    Go's integer arithmetic does on overflow."""
+
+    def __init__(self, name, bits, signed):
+        BasicType.__init__(self, name)
+        self.bits = bits
+        self.signed = signed
+        self.min = -(1 << (bits - 1)) if signed else 0
+        self.max = (1 << (bits - 1)) - 1 if signed else (1 << bits) - 1
+
+    def __call__(self, x):
+        if self.min <= x <= self.max:
+            return x
+        x &= (1 << self.bits) - 1
+        if self.signed and x > self.max:
+            x -= 1 << self.bits
+        return x
+
+    def quo(self, x, y):
+        """x / y truncated towards zero, for a signed integer type."""
+        if y == 0:
+            raise GoPanic(_runtimeError("integer divide by zero"))
+        q = builtins.abs(x) // builtins.abs(y)
+        return self(-q if (x < 0) != (y < 0) else q)
+
+    def rem(self, x, y):
+        """x % y with the sign of x, for a signed integer type."""
+        if y == 0:
+            raise GoPanic(_runtimeError("integer divide by zero"))
+        r = builtins.abs(x) % builtins.abs(y)
+        return -r if x < 0 else r
+
+    def trunc(self, x):
+        """Convert the float x to the type, truncating it towards zero."""
+        return self(builtins.int(x))
+
+
+def _float32(x):
+    # Round to the nearest float32, which is infinite if x is too large.
+    try:
+        return struct.unpack("f", struct.pack("f", x))[0]
+    except OverflowError:
+        return math.copysign(math.inf, x)
+
+
+class FloatType(BasicType):
+    """A Go floating-point type. Calling it converts a number to the type,
+    rounding it to the precision of the type."""
+
+    def __init__(self, name, bits):
+        BasicType.__init__(self, name)
+        self.bits = bits
+
+    def __call__(self, x):
+        x = builtins.float(x)
+        return _float32(x) if self.bits == 32 else x
+
+
+class ComplexType(BasicType):
+    """A Go complex type. Calling it converts a complex number to the type,
+    rounding its parts to the precision of the type."""
+
+    def __init__(self, name, bits):
+        BasicType.__init__(self, name)
+        self.bits = bits
+
+    def __call__(self, x):
+        x = builtins.complex(x)
+        if self.bits == 64:
+            x = builtins.complex(_float32(x.real), _float32(x.imag))
+        return x
+
+
+class ArrayType(Type):
+    def __init__(self, elem, length):
+        self.elem = elem
+        self.len = length
+
+    def _key(self):
+        return (self.elem, self.len)
+
+    def __repr__(self):
+        return "[%d]%r" % (self.len, self.elem)
+
+
+class SliceType(Type):
+    def __init__(self, elem):
+        self.elem = elem
+
+    def _key(self):
+        return self.elem
+
+    def __repr__(self):
+        return "[]%r" % (self.elem,)
+
+
+class PointerType(Type):
+    def __init__(self, elem):
+        self.elem = elem
+
+    def _key(self):
+        return self.elem
+
+    def __repr__(self):
+        return "*" + typeString(self.elem)
+
+
+class MapType(Type):
+    def __init__(self, key, elem):
+        self.key = key
+        self.elem = elem
+
+    def _key(self):
+        return (self.key, self.elem)
+
+    def __repr__(self):
+        return "map[%s]%s" % (typeString(self.key), typeString(self.elem))
+
+
+class ChanType(Type):
+    def __init__(self, elem, dir):
+        self.elem = elem
+        self.dir = dir
+
+    def _key(self):
+        return (self.elem, self.dir)
+
+    def __repr__(self):
+        return "%s %s" % (self.dir, typeString(self.elem))
+
+
+class FuncType(Type):
+    def __init__(self, params, results, variadic):
+        self.params = params
+        self.results = results
+        self.variadic = variadic
+
+    def _key(self):
+        return (self.params, self.results, self.variadic)
+
+    def __repr__(self):
+        params = [typeString(t) for t in self.params]
+        if self.variadic:
+            params[-1] = "..." + params[-1][2:]
+        results = [typeString(t) for t in self.results]
+        s = "func(%s)" % ", ".join(params)
+        if len(results) == 1:
+            s += " " + results[0]
+        elif results:
+            s += " (%s)" % ", ".join(results)
+        return s
+
+
+class StructType(Type):
+    """An unnamed struct type. fields is a tuple of (name, type) pairs."""
+
+    def __init__(self, fields):
+        self.fields = fields
+
+    def _key(self):
+        return self.fields
+
+    def __repr__(self):
+        return "struct { %s }" % "; ".join("%s %s" % (name, typeString(t)) for name, t in self.fields)
+
+
+class InterfaceType(Type):
+    """An unnamed interface type. Named interface types are protocol classes."""
+
+    def __init__(self, methods):
+        self.methods = methods
+
+    def _key(self):
+        return self.methods
+
+    def __repr__(self):
+        if not self.methods:
+            return "interface {}"
+        return "interface { %s }" % "; ".join(m + "()" for m in self.methods)
+
+
+def typeString(t):
+    """The Go syntax for the type descriptor t. Named types are described by
+    the classes generated for them."""
+    if t is error:
+        return "error"
+    if isinstance(t, builtins.type):
+        module = "main" if t.__module__ == "__main__" else t.__module__
+        return "%s.%s" % (module, t.__name__)
+    return repr(t)
+
+
+def arrayKey(a):
+    """A hashable key for the Go array a, which compares equal to the keys of
+    equal arrays."""
+    return builtins.tuple(arrayKey(e) if isinstance(e, builtins.list) else e for e in a)
+
+
+def _keyArray(k):
+    """The array whose key made by arrayKey is k."""
+    return [_keyArray(e) if isinstance(e, builtins.tuple) else e for e in k]
+
+
+def arrayType(elem, length):
+    return ArrayType(elem, length)
+
+
+def sliceType(elem):
+    return SliceType(elem)
+
+
+def pointerType(elem):
+    return PointerType(elem)
+
+
+def mapType(key, elem):
+    return MapType(key, elem)
+
+
+def chanType(elem, dir="chan"):
+    return ChanType(elem, dir)
+
+
+def funcType(params, results, variadic=False):
+    return FuncType(params, results, variadic)
+
+
+def structType(fields):
+    return StructType(fields)
+
+
+def interfaceType(*methods):
+    return InterfaceType(methods)
+
+
+bool = BasicType("bool")
+string = BasicType("string")
+# int, uint and uintptr are 64 bits wide, as on amd64.
+int = IntType("int", 64, True)
+int8 = IntType("int8", 8, True)
+int16 = IntType("int16", 16, True)
+int32 = IntType("int32", 32, True)
+int64 = IntType("int64", 64, True)
+uint = IntType("uint", 64, False)
+uint8 = IntType("uint8", 8, False)
+uint16 = IntType("uint16", 16, False)
+uint32 = IntType("uint32", 32, False)
+uint64 = IntType("uint64", 64, False)
+uintptr = IntType("uintptr", 64, False)
+float32 = FloatType("float32", 32)
+float64 = FloatType("float64", 64)
+complex64 = ComplexType("complex64", 64)
+complex128 = ComplexType("complex128", 128)
+byte = uint8
+rune = int32
+
+
+@typing.runtime_checkable
+class error(typing.Protocol):
+    def Error(self):
+        ...
+
+
+class Iface:
+    """An interface value whose dynamic type cannot be told from its Python
+    representation, such as an int8, a named type or a struct value.
+
+    Values of the types in _naturalTypes, and non-nil pointers to named
+    structs, are stored in interfaces as they are.
+    """
+
+    __slots__ = ("type", "value")
+
+    def __init__(self, type, value):
+        self.type = type
+        self.value = value
+
+    def __eq__(self, other):
+        return isinstance(other, Iface) and self.type == other.type and self.value == other.value
+
+    def __ne__(self, other):
+        return not self == other
+
+    def __hash__(self):
+        value = self.value
+        if isinstance(value, builtins.list):
+            value = arrayKey(value)
+        return builtins.hash((self.type, value))
+
+    def __getattr__(self, name):
+        # Call methods of the dynamic type with the value as receiver
+        if name in methodSet(self.type):
+            return functools.partial(getattr(_methodClass(self.type), name), self.value)
+        raise AttributeError(name)
+
+
+def pointerIface(p, t):
+    """The interface value of p, a pointer of type t to a named struct. A
+    nil pointer is stored with its type so that the interface is not nil."""
+    if p is None:
+        return Iface(t, None)
+    return p
+
+
+_naturalTypes = {
+    builtins.bool: bool,
+    builtins.int: int,
+    builtins.float: float64,
+    builtins.str: string,
+    builtins.complex: complex128,
+}
+
+
+def typeOf(x):
+    """The dynamic type of the interface value x, or None if x is nil."""
+    if x is None:
+        return None
+    if isinstance(x, Iface):
+        return x.type
+    t = _naturalTypes.get(builtins.type(x))
+    if t is not None:
+        return t
+    # A pointer to a struct is the struct object itself
+    return PointerType(builtins.type(x))
+
+
+def valueOf(x):
+    """The value of the interface value x, whose type is its dynamic type."""
+    if isinstance(x, Iface):
+        return x.value
+    return x
+
+
+def _methodClass(t):
+    """The class holding the methods of type t, or None."""
+    if isinstance(t, PointerType):
+        t = t.elem
+    if isinstance(t, builtins.type):
+        return t
+    return None
+
+
+def _isInterface(t):
+    return isinstance(t, InterfaceType) or getattr(t, "_is_protocol", False)
+
+
+@functools.lru_cache(maxsize=None)
+def methodSet(t):
+    """The names of the methods of type t. The method set of a named type
+    excludes the methods with pointer receivers, which are listed by the
+    _pointerMethods attribute of its class."""
+    if isinstance(t, InterfaceType):
+        return frozenset(t.methods)
+    cls = _methodClass(t)
+    if cls is None:
+        return frozenset()
+    methods = frozenset(name for name, value in vars(cls).items()
+                        if not name.startswith("_") and callable(value))
+    if cls is t and not _isInterface(t):
+        methods -= frozenset(getattr(cls, "_pointerMethods", ()))
+    return methods
+
+
+def implements(t, iface):
+    """Whether type t implements the interface type iface."""
+    return t is not None and methodSet(iface) <= methodSet(t)
+
+
+def _assertionError(x, t):
+    dynamic = typeOf(x)
+    if dynamic is None:
+        msg = "interface conversion: interface is nil, not %s" % typeString(t)
+    elif _isInterface(t):
+        missing = sorted(methodSet(t) - methodSet(dynamic))
+        msg = "interface conversion: %s is not %s: missing method %s" % (
+            typeString(dynamic), typeString(t), missing[0])
+    else:
+        msg = "interface conversion: interface is %s, not %s" % (typeString(dynamic), typeString(t))
+    return GoPanic(_plainError(msg))
+
+
+def _asserts(x, t):
+    if _isInterface(t):
+        return implements(typeOf(x), t)
+    return typeOf(x) == t
+
+
+def typeAssert(x, t):
+    """x.(t): the value of the interface value x if its dynamic type is t, or
+    x itself if t is an interface type that it implements."""
+    if not _asserts(x, t):
+        raise _assertionError(x, t)
+    if _isInterface(t):
+        return x
+    return valueOf(x)
+
+
+def typeAssertOk(x, t, zero=None):
+    """v, ok := x.(t), where zero is the zero value of t."""
+    if not _asserts(x, t):
+        return zero, False
+    if _isInterface(t):
+        return x, True
+    return valueOf(x), True
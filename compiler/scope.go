@@ -3,6 +3,8 @@ package compiler
 import (
 	"fmt"
 	py "github.com/mbergin/gotopython/pythonast"
+	"go/ast"
+	"go/token"
 	"go/types"
 )
 
@@ -13,6 +15,35 @@ type scope struct {
 	locals map[py.Identifier]bool
 }
 
+// Identifiers that generated code refers to, so compiled Go identifiers must not bind them.
+var reservedIDs = map[py.Identifier]bool{
+	runtimeModule.Id: true,
+	typingModule.Id:  true,
+	pyRange.Id:       true,
+	pyLen.Id:         true,
+	pyEnumerate.Id:   true,
+	pyType.Id:        true,
+	pyKeyError.Id:    true,
+	pyException.Id:   true,
+	pyComplex.Id:     true,
+	pyOrd.Id:         true,
+	pyHash.Id:        true,
+	pyList.Id:        true,
+	pyBytes.Id:       true,
+	pyStr.Id:         true,
+	pyChr.Id:         true,
+}
+
+// Python keywords that are not Go keywords, so can be Go identifiers.
+var pyKeywords = map[py.Identifier]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true,
+	"assert": true, "async": true, "await": true, "class": true, "def": true,
+	"del": true, "elif": true, "except": true, "finally": true, "from": true,
+	"global": true, "in": true, "is": true, "lambda": true, "nonlocal": true,
+	"not": true, "or": true, "pass": true, "raise": true, "try": true,
+	"while": true, "with": true, "yield": true,
+}
+
 func newScope() *scope {
 	return &scope{
 		ids:    make(map[types.Object]py.Identifier),
@@ -26,24 +57,105 @@ func (s *scope) nested() *scope {
 	return ns
 }
 
+// objID returns the Python name of goID. Package-level objects are named in
+// the module scope; other objects are looked up in the enclosing scopes and
+// named in this one if they are not found.
 func (s *scope) objID(goID types.Object) py.Identifier {
-	if id, ok := s.ids[goID]; ok {
-		return id
+	if pkg := goID.Pkg(); pkg != nil && goID.Parent() == pkg.Scope() {
+		for s.parent != nil {
+			s = s.parent
+		}
 	}
-	pyID := py.Identifier(goID.Name())
-	for i := 1; s.locals[pyID]; i++ {
-		pyID = py.Identifier(fmt.Sprintf("%s%d", goID.Name(), i))
+	for scope := s; scope != nil; scope = scope.parent {
+		if id, ok := scope.ids[goID]; ok {
+			return id
+		}
 	}
+	pyID := s.tempID(goID.Name())
 	s.ids[goID] = pyID
-	s.locals[pyID] = true
 	return pyID
 }
 
 func (s *scope) tempID(baseId string) py.Identifier {
 	pyID := py.Identifier(baseId)
-	for i := 1; s.locals[pyID]; i++ {
+	for i := 1; s.locals[pyID] || reservedIDs[pyID] || pyKeywords[pyID]; i++ {
 		pyID = py.Identifier(fmt.Sprintf("%s%d", baseId, i))
 	}
 	s.locals[pyID] = true
 	return pyID
 }
+
+// reserve stops the scope using id, the name of an object of an enclosing
+// scope that the scope refers to.
+func (s *scope) reserve(id py.Identifier) {
+	s.locals[id] = true
+}
+
+// fieldID returns the Python attribute name of a struct field or method.
+// Attributes are not in any scope, so they keep their Go names unless they
+// are Python keywords.
+func fieldID(obj types.Object) py.Identifier {
+	id := py.Identifier(obj.Name())
+	if pyKeywords[id] {
+		id += "_"
+	}
+	return id
+}
+
+// isNamed reports whether obj is named by objID: a variable, constant, type
+// or function that is not a field, method or builtin.
+func isNamed(obj types.Object) bool {
+	if obj == nil || obj.Parent() == types.Universe {
+		return false
+	}
+	switch obj := obj.(type) {
+	case *types.Var:
+		return !obj.IsField()
+	case *types.Const, *types.TypeName:
+		return true
+	case *types.Func:
+		return obj.Type().(*types.Signature).Recv() == nil
+	}
+	return false
+}
+
+// declareFunc names the objects declared by a function before it is
+// compiled. Go blocks do not create Python scopes, so each object gets a
+// name that is unique in the function, and that differs from the names of
+// the objects of enclosing scopes that the function refers to.
+func (c *Compiler) declareFunc(recv *ast.Ident, typ *ast.FuncType, body *ast.BlockStmt) {
+	inFunc := func(pos token.Pos) bool {
+		return pos >= typ.Pos() && pos < body.End()
+	}
+	for _, node := range []ast.Node{typ, body} {
+		ast.Inspect(node, func(n ast.Node) bool {
+			if ident, ok := n.(*ast.Ident); ok {
+				if obj := c.Uses[ident]; isNamed(obj) && !inFunc(obj.Pos()) {
+					c.reserve(c.objID(obj))
+				}
+			}
+			return true
+		})
+	}
+	if recv != nil && isNamed(c.Defs[recv]) {
+		c.objID(c.Defs[recv])
+	}
+	for _, node := range []ast.Node{typ, body} {
+		ast.Inspect(node, func(n ast.Node) bool {
+			var obj types.Object
+			switch n := n.(type) {
+			case *ast.FuncLit:
+				// Declared when the function literal is compiled
+				return false
+			case *ast.Ident:
+				obj = c.Defs[n]
+			case *ast.CaseClause:
+				obj = c.Implicits[n]
+			}
+			if isNamed(obj) {
+				c.objID(obj)
+			}
+			return true
+		})
+	}
+}
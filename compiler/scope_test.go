@@ -12,10 +12,10 @@ func Test_scope_id_different_idents(t *testing.T) {
 	x1 := scope.objID(types.NewVar(token.NoPos, nil, "x", nil))
 	x2 := scope.objID(types.NewVar(token.NoPos, nil, "x", nil))
 	if x1 != py.Identifier("x") {
-		t.Errorf("x1=", x1)
+		t.Errorf("x1=%s", x1)
 	}
 	if x2 != py.Identifier("x1") {
-		t.Errorf("x2=", x2)
+		t.Errorf("x2=%s", x2)
 	}
 }
 
@@ -25,9 +25,57 @@ func Test_scope_id_same_idents(t *testing.T) {
 	x1 := scope.objID(x)
 	x2 := scope.objID(x)
 	if x1 != py.Identifier("x") {
-		t.Errorf("x1=", x1)
+		t.Errorf("x1=%s", x1)
 	}
 	if x2 != py.Identifier("x") {
-		t.Errorf("x2=", x2)
+		t.Errorf("x2=%s", x2)
+	}
+}
+
+func Test_scope_id_python_keyword(t *testing.T) {
+	scope := newScope()
+	id := scope.objID(types.NewVar(token.NoPos, nil, "lambda", nil))
+	if id != py.Identifier("lambda1") {
+		t.Errorf("id=%s", id)
+	}
+}
+
+func Test_scope_id_builtin(t *testing.T) {
+	scope := newScope()
+	id := scope.objID(types.NewVar(token.NoPos, nil, "len", nil))
+	if id != py.Identifier("len1") {
+		t.Errorf("id=%s", id)
+	}
+}
+
+func Test_scope_id_enclosing_scope(t *testing.T) {
+	outer := newScope()
+	x := types.NewVar(token.NoPos, nil, "x", nil)
+	outer.objID(x)
+	inner := outer.nested()
+	inner.reserve(inner.objID(x))
+	if id := inner.objID(x); id != py.Identifier("x") {
+		t.Errorf("x=%s", id)
+	}
+	if id := inner.objID(types.NewVar(token.NoPos, nil, "x", nil)); id != py.Identifier("x1") {
+		t.Errorf("shadowing x=%s", id)
+	}
+}
+
+func Test_scope_id_package_level(t *testing.T) {
+	pkg := types.NewPackage("main", "main")
+	x := types.NewVar(token.NoPos, pkg, "x", nil)
+	pkg.Scope().Insert(x)
+	module := newScope()
+	inner := module.nested()
+	inner.objID(x)
+	if id, ok := module.ids[x]; !ok || id != py.Identifier("x") {
+		t.Errorf("x=%s", id)
+	}
+}
+
+func Test_fieldID_python_keyword(t *testing.T) {
+	if id := fieldID(types.NewField(token.NoPos, nil, "class", nil, false)); id != py.Identifier("class_") {
+		t.Errorf("id=%s", id)
 	}
 }
@@ -5,10 +5,17 @@ import (
 	py "github.com/mbergin/gotopython/pythonast"
 	"go/ast"
 	"go/token"
+	"go/types"
+	"strconv"
 	"strings"
 )
 
 func (c *Compiler) compileStmts(stmts []ast.Stmt) []py.Stmt {
+	for _, stmt := range stmts {
+		if c.isGotoTarget(stmt) {
+			return c.compileGotoBlock(stmts)
+		}
+	}
 	var pyStmts []py.Stmt
 	for _, blockStmt := range stmts {
 		pyStmts = append(pyStmts, c.compileStmt(blockStmt)...)
@@ -22,70 +29,228 @@ func (c *Compiler) isBlank(expr ast.Expr) bool {
 }
 
 func (c *Compiler) compileRangeStmt(stmt *ast.RangeStmt) []py.Stmt {
+	if sig, ok := c.TypeOf(stmt.X).Underlying().(*types.Signature); ok {
+		return c.compileRangeFunc(stmt, sig)
+	}
 	e := c.exprCompiler()
+	var defs []*ast.Ident
+	if stmt.Tok == token.DEFINE {
+		for _, x := range []ast.Expr{stmt.Key, stmt.Value} {
+			if ident, ok := x.(*ast.Ident); ok {
+				defs = append(defs, ident)
+			}
+		}
+		c.declareLoopVars(stmt, stmt.Body, defs)
+	}
+	// Before Go 1.22 the iterations share the variables, so boxed variables
+	// are boxed once and the loop assigns the values in their boxes.
+	shared := !c.loopVarPerIteration()
+	var init []py.Stmt
+	blank := &py.Name{Id: py.Identifier("_")}
+	loopVar := func(x ast.Expr) py.Expr {
+		if x == nil || c.isBlank(x) {
+			return blank
+		}
+		target := e.compileTarget(x)
+		if ident, ok := x.(*ast.Ident); ok && shared && stmt.Tok == token.DEFINE && c.isBoxed(c.Defs[ident]) {
+			init = append(init, &py.Assign{
+				Targets: []py.Expr{target},
+				Value:   runtimeCall("Box", c.zeroValue(c.TypeOf(x))),
+			})
+			return &py.Attribute{Value: target, Attr: py.Identifier("value")}
+		}
+		return target
+	}
+	// The target of iterators that produce keys and values
+	keyValue := func() py.Expr {
+		if stmt.Key == nil {
+			return blank
+		}
+		return &py.Tuple{Elts: []py.Expr{loopVar(stmt.Key), loopVar(stmt.Value)}}
+	}
+	hasValue := stmt.Value != nil && !c.isBlank(stmt.Value)
+	// The range expression is evaluated once, so the values of an array are
+	// those of a copy of it
+	_, copyArray := c.TypeOf(stmt.X).Underlying().(*types.Array)
+	copyArray = copyArray && hasValue
+	l := c.pushLoop(stmt)
 	body := c.compileStmt(stmt.Body)
+	after := c.popLoop()
+	// Iteration values are copies of struct and array elements
+	if hasValue && !copyArray && isValueType(c.TypeOf(stmt.Value)) {
+		copyStmt := &py.Assign{
+			Targets: []py.Expr{e.compileTarget(stmt.Value)},
+			Value:   c.copyValue(e.compileExpr(stmt.Value), c.TypeOf(stmt.Value)),
+		}
+		body = append([]py.Stmt{copyStmt}, body...)
+	}
+	if !shared {
+		body = append(c.boxDefs(defs...), body...)
+	}
 	if len(body) == 0 {
 		body = []py.Stmt{&py.Pass{}}
 	}
-	var pyStmt py.Stmt
-	if stmt.Key != nil && stmt.Value == nil {
-		pyStmt = &py.For{
-			Target: e.compileExpr(stmt.Key),
-			Iter: &py.Call{
-				Func: pyRange,
-				Args: []py.Expr{
-					&py.Call{
-						Func: pyLen,
-						Args: []py.Expr{e.compileExpr(stmt.X)},
-					},
-				}},
-			Body: body,
-		}
-
-	} else if stmt.Key != nil && stmt.Value != nil {
-		if c.isBlank(stmt.Key) {
-			pyStmt = &py.For{
-				Target: e.compileExpr(stmt.Value),
-				Iter:   e.compileExpr(stmt.X),
-				Body:   body,
-			}
+	x := e.compileExpr(stmt.X)
+	if copyArray {
+		x = c.copyValue(x, c.TypeOf(stmt.X))
+	}
+	var target, iter py.Expr
+	switch t := c.TypeOf(stmt.X).Underlying().(type) {
+	case *types.Chan:
+		// Receive values until the channel is closed
+		target, iter = loopVar(stmt.Key), x
+	case *types.Map:
+		var args []py.Expr
+		if _, ok := t.Key().Underlying().(*types.Array); ok {
+			// Array keys are stored as tuples
+			args = append(args, pyTrue)
+		}
+		target, iter = keyValue(), runtimeCall("rangeMap", append([]py.Expr{x}, args...)...)
+	case *types.Basic:
+		if t.Info()&types.IsString != 0 {
+			// Byte offsets and runes
+			target, iter = keyValue(), runtimeCall("rangeString", x)
+		} else {
+			target, iter = loopVar(stmt.Key), &py.Call{Func: pyRange, Args: []py.Expr{x}}
+		}
+	default:
+		switch {
+		case stmt.Key == nil:
+			target, iter = blank, x
+		case !hasValue:
+			target = loopVar(stmt.Key)
+			iter = &py.Call{Func: pyRange, Args: []py.Expr{&py.Call{Func: pyLen, Args: []py.Expr{x}}}}
+		case c.isBlank(stmt.Key):
+			target, iter = loopVar(stmt.Value), x
+		default:
+			target = keyValue()
+			iter = &py.Call{Func: pyEnumerate, Args: []py.Expr{x}}
+		}
+	}
+	stmts := append(e.stmts, init...)
+	stmts = append(stmts, l.initFlags()...)
+	stmts = append(stmts, &py.For{Target: target, Iter: iter, Body: body})
+	return append(stmts, after...)
+}
 
+// compileRangeFunc compiles a loop over the values that an iterator function
+// passes to its yield function. The loop body is compiled to a function that
+// runtime.rangeFunc calls when the iterator yields:
+//
+//	def body(k, v):
+//	    ...
+//	    return True
+//	runtime.rangeFunc(seq, body)
+func (c *Compiler) compileRangeFunc(stmt *ast.RangeStmt, sig *types.Signature) []py.Stmt {
+	e := c.exprCompiler()
+	seq := e.compileExpr(stmt.X)
+	nested := false
+	for _, l := range c.loops {
+		nested = nested || l.isFunc
+	}
+	l := c.pushLoop(stmt)
+	l.isFunc = true
+	name := &py.Name{Id: c.tempID("body")}
+
+	// The iteration values are the arguments of the body
+	var args py.Arguments
+	var defs []*ast.Ident
+	var assign []py.Stmt
+	yield := sig.Params().At(0).Type().Underlying().(*types.Signature)
+	vars := []ast.Expr{stmt.Key, stmt.Value}[:yield.Params().Len()]
+	for _, x := range vars {
+		switch {
+		case x == nil || c.isBlank(x):
+			args.Args = append(args.Args, py.Arg{Arg: py.Identifier("_")})
+		case stmt.Tok == token.DEFINE:
+			ident := x.(*ast.Ident)
+			defs = append(defs, ident)
+			args.Args = append(args.Args, py.Arg{Arg: c.identifier(ident)})
+		default:
+			arg := &py.Name{Id: c.tempID("arg")}
+			args.Args = append(args.Args, py.Arg{Arg: arg.Id})
+			ae := c.exprCompiler()
+			target := ae.compileTarget(x)
+			assign = append(append(assign, ae.stmts...), &py.Assign{Targets: []py.Expr{target}, Value: arg})
+		}
+	}
+	if len(defs) == 0 && len(assign) == 0 && len(vars) > 0 {
+		args = py.Arguments{Vararg: &py.Arg{Arg: py.Identifier("_")}}
+	}
+
+	stmts := c.compileStmt(stmt.Body)
+	after := c.popLoop()
+
+	// Variables outside the loop that the body assigns must be declared,
+	// or Python would make them local.
+	var globals, nonlocals []py.Identifier
+	seen := map[py.Identifier]bool{}
+	for _, obj := range c.assignedVars(&ast.BlockStmt{List: []ast.Stmt{stmt}}) {
+		if c.isBoxed(obj) || obj.Pos() >= stmt.Pos() && obj.Pos() < stmt.End() {
+			continue
+		}
+		id := c.objID(obj)
+		seen[id] = true
+		if obj.Parent() == obj.Pkg().Scope() {
+			globals = append(globals, id)
 		} else {
-			pyStmt = &py.For{
-				Target: &py.Tuple{Elts: []py.Expr{e.compileExpr(stmt.Key), e.compileExpr(stmt.Value)}},
-				Iter: &py.Call{
-					Func: pyEnumerate,
-					Args: []py.Expr{e.compileExpr(stmt.X)},
-				},
-				Body: body,
-			}
+			nonlocals = append(nonlocals, id)
 		}
+	}
+	for _, id := range l.nonlocals {
+		if !seen[id] {
+			nonlocals = append(nonlocals, id)
+		}
+	}
+	var body []py.Stmt
+	if globals != nil {
+		body = append(body, &py.Global{Names: globals})
+	}
+	if nonlocals != nil {
+		body = append(body, &py.Nonlocal{Names: nonlocals})
+	}
+	body = append(body, l.initFlags()...)
+	body = append(body, assign...)
+	body = append(body, c.boxDefs(defs...)...)
+	body = append(body, stmts...)
+	if len(body) == 0 {
+		body = append(body, &py.Return{Value: pyTrue})
+	} else if _, ok := body[len(body)-1].(*py.Return); !ok {
+		body = append(body, &py.Return{Value: pyTrue})
+	}
 
-	} else if stmt.Key == nil && stmt.Value == nil {
-		pyStmt = &py.For{
-			Target: &py.Name{Id: py.Identifier("_")},
-			Iter:   e.compileExpr(stmt.X),
-			Body:   body,
+	pyStmts := e.stmts
+	if l.returns && !nested {
+		pyStmts = append(pyStmts, &py.Assign{Targets: []py.Expr{c.returned}, Value: pyFalse})
+		if c.result != nil {
+			pyStmts = append(pyStmts, &py.Assign{Targets: []py.Expr{c.result}, Value: pyNone})
 		}
-	} else {
-		panic(c.err(stmt, "key == nil and value != nil in range for"))
 	}
-	return append(e.stmts, pyStmt)
+	pyStmts = append(pyStmts,
+		&py.FunctionDef{Name: name.Id, Args: args, Body: body},
+		&py.ExprStmt{Value: runtimeCall("rangeFunc", seq, name)},
+	)
+	if l.returns {
+		// Pass on a return from the body
+		var ret py.Stmt = &py.Return{Value: pyFalse}
+		if !nested {
+			ret = &py.Return{Value: nil}
+			if c.result != nil {
+				ret = &py.Return{Value: c.result}
+			}
+		}
+		pyStmts = append(pyStmts, &py.If{Test: c.returned, Body: []py.Stmt{ret}})
+	}
+	return append(pyStmts, after...)
 }
 
 func (c *Compiler) compileIncDecStmt(s *ast.IncDecStmt) []py.Stmt {
 	e := c.exprCompiler()
-	var op py.Operator
-	if s.Tok == token.INC {
-		op = py.Add
-	} else {
-		op = py.Sub
-	}
-	stmt := &py.AugAssign{
-		Target: e.compileExpr(s.X),
-		Value:  &py.Num{N: "1"},
-		Op:     op,
+	op := token.ADD
+	if s.Tok == token.DEC {
+		op = token.SUB
 	}
+	stmt := e.compileAugAssign(s.X, op, &py.Num{N: "1"})
 	return append(e.stmts, stmt)
 }
 
@@ -104,15 +269,29 @@ func (c *Compiler) compileValueSpec(spec *ast.ValueSpec) []py.Stmt {
 	// var x, y int = 1, 2    x, y = 1, 2
 	// var x, y int = f()     x, y = f()
 
+	// var v, ok = <-ch   v, ok = ch.recvOk()
+	if len(spec.Names) == 2 && len(spec.Values) == 1 {
+		if value := e.compileCommaOk(spec.Values[0]); value != nil {
+			stmt := &py.Assign{
+				Targets: []py.Expr{c.compileIdent(spec.Names[0]), c.compileIdent(spec.Names[1])},
+				Value:   value,
+			}
+			return append(append(e.stmts, stmt), c.boxDefs(spec.Names...)...)
+		}
+	}
+
 	for i, ident := range spec.Names {
 		target := c.compileIdent(ident)
 
-		if len(spec.Values) == 0 {
+		if obj, ok := c.Defs[ident].(*types.Const); ok {
+			// The values of implicitly repeated constant specs depend on iota
+			values = append(values, constValue(obj.Val(), obj.Type()))
+		} else if len(spec.Values) == 0 {
 			value := c.zeroValue(c.TypeOf(ident))
-			values = append(values, value)
+			values = append(values, c.boxValue(ident, value))
 		} else if i < len(spec.Values) {
-			value := e.compileExpr(spec.Values[i])
-			values = append(values, value)
+			value := e.compileValue(spec.Values[i], c.TypeOf(ident))
+			values = append(values, c.boxValue(ident, value))
 		}
 
 		targets = append(targets, target)
@@ -121,7 +300,20 @@ func (c *Compiler) compileValueSpec(spec *ast.ValueSpec) []py.Stmt {
 		Targets: targets,
 		Value:   makeTuple(values...),
 	}
-	return append(e.stmts, stmt)
+	stmts := append(e.stmts, stmt)
+	if len(values) < len(targets) {
+		stmts = append(stmts, c.boxDefs(spec.Names...)...)
+	}
+	return stmts
+}
+
+// boxValue boxes value if it initializes ident, a variable whose address
+// is taken.
+func (c *Compiler) boxValue(ident *ast.Ident, value py.Expr) py.Expr {
+	if obj := c.Defs[ident]; obj != nil && c.isBoxed(obj) {
+		return runtimeCall("Box", value)
+	}
+	return value
 }
 
 func (c *Compiler) compileDeclStmt(s *ast.DeclStmt) []py.Stmt {
@@ -133,7 +325,7 @@ func (c *Compiler) compileDeclStmt(s *ast.DeclStmt) []py.Stmt {
 		case *ast.ValueSpec:
 			compiled = c.compileValueSpec(spec)
 		case *ast.TypeSpec:
-			compiled = []py.Stmt{c.compileTypeSpec(spec)}
+			compiled = []py.Stmt{c.compileTypeSpec(genDecl, spec)}
 		default:
 			panic(c.err(s, "unknown Spec: %T", spec))
 		}
@@ -142,29 +334,31 @@ func (c *Compiler) compileDeclStmt(s *ast.DeclStmt) []py.Stmt {
 	return stmts
 }
 
-func (c *Compiler) augmentedOp(t token.Token) py.Operator {
+// augmentedOp returns the binary operator of an assignment operator.
+func (c *Compiler) augmentedOp(t token.Token) token.Token {
 	switch t {
 	case token.ADD_ASSIGN: // +=
-		return py.Add
+		return token.ADD
 	case token.SUB_ASSIGN: // -=
-		return py.Sub
+		return token.SUB
 	case token.MUL_ASSIGN: // *=
-		return py.Mult
+		return token.MUL
 	case token.QUO_ASSIGN: // /=
-		return py.FloorDiv
+		return token.QUO
 	case token.REM_ASSIGN: // %=
-		return py.Mod
+		return token.REM
 	case token.AND_ASSIGN: // &=
-		return py.BitAnd
+		return token.AND
 	case token.OR_ASSIGN: // |=
-		return py.BitOr
+		return token.OR
 	case token.XOR_ASSIGN: // ^=
-		return py.BitXor
+		return token.XOR
 	case token.SHL_ASSIGN: // <<=
-		return py.LShift
+		return token.SHL
 	case token.SHR_ASSIGN: // >>=
-		return py.RShift
-		// &^= is special cased in compileAssignStmt
+		return token.SHR
+	case token.AND_NOT_ASSIGN: // &^=
+		return token.AND_NOT
 	default:
 		panic(fmt.Sprintf("augmentedOp bad token %v", t))
 	}
@@ -174,73 +368,216 @@ func (c *Compiler) compileAssignStmt(s *ast.AssignStmt) []py.Stmt {
 	e := c.exprCompiler()
 	var stmt py.Stmt
 	if s.Tok == token.ASSIGN || s.Tok == token.DEFINE {
+		if len(s.Lhs) == 1 && s.Tok == token.ASSIGN && e.storesInPlace(s.Lhs[0]) {
+			// *p = v, where p points to a struct or array
+			value := e.compileValue(s.Rhs[0], c.TypeOf(s.Lhs[0]))
+			store := &py.ExprStmt{Value: runtimeCall("store", e.compileAddress(s.Lhs[0]), value)}
+			return append(e.stmts, store)
+		}
+		targets := e.compileTargets(s.Lhs)
+		var value py.Expr
+		var boxes []py.Stmt
+		if len(s.Lhs) == 2 && len(s.Rhs) == 1 {
+			value = e.compileCommaOk(s.Rhs[0])
+		}
+		if value == nil && len(s.Lhs) == len(s.Rhs) {
+			values := e.compileInOrder(s.Rhs, func(i int, rhs ast.Expr) py.Expr {
+				value := e.compileValue(rhs, c.TypeOf(s.Lhs[i]))
+				if ident, ok := s.Lhs[i].(*ast.Ident); ok {
+					value = c.boxValue(ident, value)
+				}
+				return value
+			})
+			value = makeTuple(values...)
+		} else {
+			for _, lhs := range s.Lhs {
+				if ident, ok := lhs.(*ast.Ident); ok {
+					boxes = append(boxes, c.boxDefs(ident)...)
+				}
+			}
+		}
+		if value == nil {
+			value = e.compileExprsTuple(s.Rhs)
+		}
 		stmt = &py.Assign{
-			Targets: e.compileExprs(s.Lhs),
-			Value:   e.compileExprsTuple(s.Rhs),
-		}
-	} else if s.Tok == token.AND_NOT_ASSIGN { // x &^= y becomes x &= ~y
-		stmt = &py.AugAssign{
-			Target: e.compileExpr(s.Lhs[0]),
-			Value: &py.UnaryOpExpr{
-				Op:      py.Invert,
-				Operand: e.compileExpr(s.Rhs[0]),
-			},
-			Op: py.BitAnd,
+			Targets: targets,
+			Value:   value,
 		}
+		return append(append(e.stmts, stmt), boxes...)
 	} else {
-		stmt = &py.AugAssign{
-			Target: e.compileExpr(s.Lhs[0]),
-			Value:  e.compileExpr(s.Rhs[0]),
-			Op:     c.augmentedOp(s.Tok),
-		}
+		stmt = e.compileAugAssign(s.Lhs[0], c.augmentedOp(s.Tok), e.compileExpr(s.Rhs[0]))
 	}
 	return append(e.stmts, stmt)
 }
 
+// compileAugAssign compiles target op= value. If the operation cannot be
+// compiled to a Python augmented assignment, e.g. because it can overflow
+// the integer type of target, it is compiled as target = target op value.
+func (c *exprCompiler) compileAugAssign(target ast.Expr, op token.Token, value py.Expr) py.Stmt {
+	typ := c.TypeOf(target)
+	placeholder := &py.Name{}
+	m := c.mapOf(target)
+	if bin, ok := c.arith(op, typ, placeholder, value).(*py.BinOp); ok && bin.Left == placeholder && m == nil {
+		return &py.AugAssign{Target: c.compileExpr(target), Op: bin.Op, Value: bin.Right}
+	}
+	// target is evaluated once, as in Go
+	var store, load py.Expr
+	switch t := unparen(target).(type) {
+	case *ast.IndexExpr:
+		x := c.compileOnce(t.X)
+		var index py.Expr
+		if m != nil {
+			index = c.once(t.Index, c.compileKey(t.Index, m.Key()))
+		} else {
+			index = c.compileOnce(t.Index)
+		}
+		store = &py.Subscript{Value: x, Slice: &py.Index{Value: index}}
+		load = &py.Subscript{Value: x, Slice: &py.Index{Value: index}}
+		if m != nil {
+			// A missing entry of a map is loaded as the zero value
+			load = runtimeCall("mapIndex", x, index, c.zeroValue(m.Elem()))
+		}
+	case *ast.SelectorExpr:
+		x := c.compileOnce(t.X)
+		store = &py.Attribute{Value: x, Attr: c.selectorID(t)}
+		load = &py.Attribute{Value: x, Attr: c.selectorID(t)}
+	default:
+		store = c.compileExpr(target)
+		load = c.compileExpr(target)
+	}
+	return &py.Assign{
+		Targets: []py.Expr{store},
+		Value:   c.arith(op, typ, load, value),
+	}
+}
+
 func (c *Compiler) compileSwitchStmt(s *ast.SwitchStmt) []py.Stmt {
-	e := c.exprCompiler()
 	var stmts []py.Stmt
 	if s.Init != nil {
 		stmts = append(stmts, c.compileStmt(s.Init)...)
 	}
 	var tag py.Expr
 	if s.Tag != nil {
-		tag = &py.Name{Id: py.Identifier("tag")}
-		assignTag := &py.Assign{Targets: []py.Expr{tag}, Value: e.compileExpr(s.Tag)}
-		stmts = append(stmts, assignTag)
+		e := c.exprCompiler()
+		tag = &py.Name{Id: c.tempID("tag")}
+		value := e.compileExpr(s.Tag)
+		stmts = append(stmts, e.stmts...)
+		stmts = append(stmts, &py.Assign{Targets: []py.Expr{tag}, Value: value})
+	}
+	if hasFallthrough(s.Body.List) {
+		return append(stmts, c.compileFallthroughClauses(s.Body.List, tag)...)
 	}
 
-	var firstIfStmt *py.If
-	var lastIfStmt *py.If
+	var conds []condition
 	var defaultBody []py.Stmt
 	for _, stmt := range s.Body.List {
 		caseClause := stmt.(*ast.CaseClause)
+		e := c.exprCompiler()
 		test := e.compileCaseClauseTest(caseClause, tag)
 		if test == nil {
 			// no test => default clause
 			defaultBody = c.compileStmts(caseClause.Body)
 			continue
 		}
-		ifStmt := &py.If{Test: test, Body: c.compileStmts(caseClause.Body)}
-		if firstIfStmt == nil {
-			firstIfStmt = ifStmt
-			lastIfStmt = ifStmt
-		} else {
-			lastIfStmt.Orelse = []py.Stmt{ifStmt}
-			lastIfStmt = ifStmt
-		}
+		conds = append(conds, condition{stmts: e.stmts, test: test, body: c.compileStmts(caseClause.Body)})
 	}
-	stmts = append(stmts, e.stmts...)
-	if lastIfStmt != nil {
-		lastIfStmt.Orelse = defaultBody
-		stmts = append(stmts, firstIfStmt)
-	} else {
-		// no cases apart from default
-		stmts = append(stmts, defaultBody...)
+	return append(stmts, ifChain(conds, defaultBody)...)
+}
+
+// A condition is a test of an if-chain, the statements hoisted from the
+// test, and the statements that run if the test is true.
+type condition struct {
+	stmts []py.Stmt
+	test  py.Expr
+	body  []py.Stmt
+}
+
+// ifChain compiles an if-elif-else chain that tests conds in order and runs
+// orelse if none of them is true. A test is evaluated only if the tests
+// before it are false, so the statements hoisted from it run in the else
+// branch of the test before it.
+func ifChain(conds []condition, orelse []py.Stmt) []py.Stmt {
+	stmts := orelse
+	for i := len(conds) - 1; i >= 0; i-- {
+		cond := conds[i]
+		ifStmt := &py.If{Test: cond.test, Body: cond.body, Orelse: stmts}
+		stmts = append(append([]py.Stmt{}, cond.stmts...), ifStmt)
 	}
 	return stmts
 }
 
+// fallthroughStmt returns the fallthrough statement ending body, or nil.
+func fallthroughStmt(body []ast.Stmt) *ast.BranchStmt {
+	for i := len(body) - 1; i >= 0; i-- {
+		if _, ok := body[i].(*ast.EmptyStmt); ok {
+			continue
+		}
+		if s, ok := body[i].(*ast.BranchStmt); ok && s.Tok == token.FALLTHROUGH {
+			return s
+		}
+		return nil
+	}
+	return nil
+}
+
+func hasFallthrough(clauses []ast.Stmt) bool {
+	for _, stmt := range clauses {
+		if fallthroughStmt(stmt.(*ast.CaseClause).Body) != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// compileFallthroughClauses compiles the clauses of a switch statement that
+// contains fallthrough statements. The index of the matching clause is found
+// first, then the clause bodies run in order, a fallthrough setting the index
+// to that of the next clause:
+//
+//	if tag == x:
+//	    clause = 0
+//	else:
+//	    clause = 1
+//	if clause == 0:
+//	    ...
+//	    clause = 1
+//	if clause == 1:
+//	    ...
+func (c *Compiler) compileFallthroughClauses(clauses []ast.Stmt, tag py.Expr) []py.Stmt {
+	clause := &py.Name{Id: c.tempID("clause")}
+	setClause := func(i int) py.Stmt {
+		return &py.Assign{Targets: []py.Expr{clause}, Value: &py.Num{N: strconv.Itoa(i)}}
+	}
+	var conds []condition
+	defaultBody := []py.Stmt{setClause(-1)}
+	var bodies []py.Stmt
+	for i, stmt := range clauses {
+		caseClause := stmt.(*ast.CaseClause)
+		e := c.exprCompiler()
+		if test := e.compileCaseClauseTest(caseClause, tag); test == nil {
+			defaultBody = []py.Stmt{setClause(i)}
+		} else {
+			conds = append(conds, condition{stmts: e.stmts, test: test, body: []py.Stmt{setClause(i)}})
+		}
+		body := c.compileStmts(caseClause.Body)
+		if fallthroughStmt(caseClause.Body) != nil {
+			body = append(body, setClause(i+1))
+		}
+		if len(body) == 0 {
+			continue
+		}
+		test := &py.Compare{
+			Left:        clause,
+			Ops:         []py.CmpOp{py.Eq},
+			Comparators: []py.Expr{&py.Num{N: strconv.Itoa(i)}},
+		}
+		bodies = append(bodies, &py.If{Test: test, Body: body})
+	}
+	return append(ifChain(conds, defaultBody), bodies...)
+}
+
+// compileTypeSwitchStmt compiles a type switch to an if-chain that tests
+// the dynamic type of the operand.
 func (c *Compiler) compileTypeSwitchStmt(s *ast.TypeSwitchStmt) []py.Stmt {
 	e := c.exprCompiler()
 	var stmts []py.Stmt
@@ -248,25 +585,28 @@ func (c *Compiler) compileTypeSwitchStmt(s *ast.TypeSwitchStmt) []py.Stmt {
 	if s.Init != nil {
 		stmts = append(stmts, c.compileStmt(s.Init)...)
 	}
-	var tag py.Expr
-	var symbolicVarName string
+	var value py.Expr
 	var typeAssert ast.Expr
 
 	switch s := s.Assign.(type) {
 	case *ast.AssignStmt:
 		ident := s.Lhs[0].(*ast.Ident)
-		tag = &py.Name{Id: c.tempID(ident.Name)}
-		symbolicVarName = ident.Name
+		value = &py.Name{Id: c.tempID(ident.Name)}
 		typeAssert = s.Rhs[0]
 	case *ast.ExprStmt:
-		tag = &py.Name{Id: c.tempID("tag")}
 		typeAssert = s.X
 	default:
 		panic(c.err(s, "Unknown statement type in type switch assign: %T", s))
 	}
 	expr := typeAssert.(*ast.TypeAssertExpr).X
-	tagValue := &py.Call{Func: pyType, Args: []py.Expr{e.compileExpr(expr)}}
-	assignTag := &py.Assign{Targets: []py.Expr{tag}, Value: tagValue}
+	tagValue := e.compileExpr(expr)
+	if value != nil {
+		// The value is bound to a variable in each clause
+		stmts = append(stmts, &py.Assign{Targets: []py.Expr{value}, Value: tagValue})
+		tagValue = value
+	}
+	tag := &py.Name{Id: c.tempID("tag")}
+	assignTag := &py.Assign{Targets: []py.Expr{tag}, Value: runtimeCall("typeOf", tagValue)}
 	stmts = append(stmts, assignTag)
 
 	var firstIfStmt *py.If
@@ -274,15 +614,10 @@ func (c *Compiler) compileTypeSwitchStmt(s *ast.TypeSwitchStmt) []py.Stmt {
 	var defaultBody []py.Stmt
 	for _, stmt := range s.Body.List {
 		caseClause := stmt.(*ast.CaseClause)
-		test := e.compileCaseClauseTest(caseClause, tag)
+		test := e.compileTypeCaseTest(caseClause, tag)
 		var bodyStmts []py.Stmt
-		if symbolicVarName != "" {
-			typedIdent := c.objID(c.Implicits[caseClause])
-			assign := &py.Assign{
-				Targets: []py.Expr{&py.Name{Id: typedIdent}},
-				Value:   tag,
-			}
-			bodyStmts = append(bodyStmts, assign)
+		if obj := c.Implicits[caseClause]; obj != nil {
+			bodyStmts = append(bodyStmts, c.bindTypeCase(caseClause, obj, value))
 		}
 		bodyStmts = append(bodyStmts, c.compileStmts(caseClause.Body)...)
 		if test == nil {
@@ -310,6 +645,50 @@ func (c *Compiler) compileTypeSwitchStmt(s *ast.TypeSwitchStmt) []py.Stmt {
 	return stmts
 }
 
+// compileTypeCaseTest compiles a test of whether tag, a dynamic type, matches
+// one of the types of a type switch clause. It returns nil for the default
+// clause.
+func (c *exprCompiler) compileTypeCaseTest(caseClause *ast.CaseClause, tag py.Expr) py.Expr {
+	var tests []py.Expr
+	for _, expr := range caseClause.List {
+		var test py.Expr
+		typ := c.TypeOf(expr)
+		switch {
+		case c.isNil(expr):
+			test = &py.Compare{Left: tag, Ops: []py.CmpOp{py.Is}, Comparators: []py.Expr{pyNone}}
+		case types.IsInterface(typ):
+			test = runtimeCall("implements", tag, c.compileType(typ))
+		default:
+			test = &py.Compare{Left: tag, Ops: []py.CmpOp{py.Eq}, Comparators: []py.Expr{c.compileType(typ)}}
+		}
+		tests = append(tests, test)
+	}
+	if len(tests) == 0 {
+		return nil
+	} else if len(tests) == 1 {
+		return tests[0]
+	}
+	return &py.BoolOpExpr{Op: py.Or, Values: tests}
+}
+
+// bindTypeCase assigns value, the operand of a type switch, to the variable
+// obj declared in caseClause. In a clause with a single non-interface type
+// the variable has that type, otherwise it has the type of the operand.
+func (c *Compiler) bindTypeCase(caseClause *ast.CaseClause, obj types.Object, value py.Expr) py.Stmt {
+	var bound py.Expr = value
+	typ := obj.Type()
+	if len(caseClause.List) == 1 && !c.isNil(caseClause.List[0]) && !types.IsInterface(typ) {
+		bound = runtimeCall("valueOf", value)
+		if isValueType(typ) && c.modifies(&ast.BlockStmt{List: caseClause.Body}, obj) {
+			bound = c.copyValue(bound, typ)
+		}
+	}
+	if c.isBoxed(obj) {
+		bound = runtimeCall("Box", bound)
+	}
+	return &py.Assign{Targets: []py.Expr{&py.Name{Id: c.objID(obj)}}, Value: bound}
+}
+
 func (c *Compiler) compileIfStmt(s *ast.IfStmt) []py.Stmt {
 	e := c.exprCompiler()
 	var stmts []py.Stmt
@@ -327,38 +706,230 @@ func (c *Compiler) compileIfStmt(s *ast.IfStmt) []py.Stmt {
 
 func (c *Compiler) compileBranchStmt(s *ast.BranchStmt) []py.Stmt {
 	switch s.Tok {
-	case token.BREAK:
-		return []py.Stmt{&py.Break{}}
-	case token.CONTINUE:
-		return []py.Stmt{&py.Continue{}}
+	case token.BREAK, token.CONTINUE:
+		return c.branch(c.branchTarget(s), s.Tok == token.CONTINUE)
+	case token.GOTO:
+		return c.compileGoto(s)
 	case token.FALLTHROUGH:
-		return []py.Stmt{&py.ExprStmt{Value: &py.Call{Func: &py.Name{Id: py.Identifier("_TODO_fallthrough")}}}}
+		// compileFallthroughClauses moves on to the next clause
+		return nil
 	default:
 		panic(c.err(s, "unknown BranchStmt %v", s.Tok))
 	}
 }
 
 func (c *Compiler) compileForStmt(s *ast.ForStmt) []py.Stmt {
+	var vars []*ast.Ident
+	if init, ok := s.Init.(*ast.AssignStmt); ok && init.Tok == token.DEFINE {
+		for _, lhs := range init.Lhs {
+			vars = append(vars, lhs.(*ast.Ident))
+		}
+	}
+	c.declareLoopVars(s, s.Body, vars)
+	if stmts := c.compileForRange(s); stmts != nil {
+		return stmts
+	}
 	e := c.exprCompiler()
 	var stmts []py.Stmt
-	body := c.compileStmt(s.Body)
-	if s.Post != nil {
-		body = append(c.compileStmt(s.Body), c.compileStmt(s.Post)...)
-	}
 	if s.Init != nil {
 		stmts = c.compileStmt(s.Init)
 	}
 	var test py.Expr = pyTrue
+	var cond []py.Stmt
 	if s.Cond != nil {
 		test = e.compileExpr(s.Cond)
 	}
+	if len(e.stmts) > 0 {
+		// The statements hoisted from the condition run on every iteration
+		cond = append(e.stmts, &py.If{
+			Test: &py.UnaryOpExpr{Op: py.Not, Operand: test},
+			Body: []py.Stmt{&py.Break{}},
+		})
+		test = pyTrue
+	}
+	l := c.pushLoop(s)
+	// The post statement runs after the body and before each continue
+	l.post = c.renewLoopVars(vars)
+	if s.Post != nil {
+		l.post = append(l.post, c.compileStmt(s.Post)...)
+	}
+	body := c.compileStmt(s.Body)
+	if n := len(body); n == 0 {
+		body = l.post
+	} else if _, ok := body[n-1].(*py.Continue); !ok {
+		body = append(body, l.post...)
+	}
+	after := c.popLoop()
+	body = append(cond, body...)
 
 	if len(body) == 0 {
 		body = []py.Stmt{&py.Pass{}}
 	}
 
-	stmts = append(stmts, e.stmts...)
+	stmts = append(stmts, l.initFlags()...)
 	stmts = append(stmts, &py.While{Test: test, Body: body})
+	return append(stmts, after...)
+}
+
+// compileForRange compiles a for statement of the form
+//
+//	for i := a; i < b; i++ { ... }
+//
+// to a Python for statement over range(a, b), provided that the body does not
+// modify i and b is the same on every iteration. It returns nil if s is not
+// of this form.
+func (c *Compiler) compileForRange(s *ast.ForStmt) []py.Stmt {
+	init, ok := s.Init.(*ast.AssignStmt)
+	if !ok || init.Tok != token.DEFINE || len(init.Lhs) != 1 || len(init.Rhs) != 1 {
+		return nil
+	}
+	obj := c.Defs[init.Lhs[0].(*ast.Ident)]
+	cond, ok := s.Cond.(*ast.BinaryExpr)
+	if !ok || cond.Op != token.LSS || !c.isVar(cond.X, obj) {
+		return nil
+	}
+	post, ok := s.Post.(*ast.IncDecStmt)
+	if !ok || post.Tok != token.INC || !c.isVar(post.X, obj) {
+		return nil
+	}
+	if basic, ok := obj.Type().Underlying().(*types.Basic); !ok || basic.Info()&types.IsInteger == 0 {
+		return nil
+	}
+	if c.addressed[obj] || c.modifies(s.Body, obj) || !c.isInvariant(cond.Y) {
+		return nil
+	}
+	if !c.loopVarPerIteration() && c.isCaptured(s, obj) {
+		// Function literals would see the last value of the range rather
+		// than b after the loop ends
+		return nil
+	}
+	e := c.exprCompiler()
+	target := e.compileExpr(init.Lhs[0])
+	var args []py.Expr
+	if start := e.compileExpr(init.Rhs[0]); !isZeroNum(start) {
+		args = append(args, start)
+	}
+	args = append(args, e.compileExpr(cond.Y))
+	l := c.pushLoop(s)
+	body := c.compileStmt(s.Body)
+	after := c.popLoop()
+	if len(body) == 0 {
+		body = []py.Stmt{&py.Pass{}}
+	}
+	stmts := append(e.stmts, l.initFlags()...)
+	stmts = append(stmts, &py.For{Target: target, Iter: &py.Call{Func: pyRange, Args: args}, Body: body})
+	return append(stmts, after...)
+}
+
+func isZeroNum(expr py.Expr) bool {
+	num, ok := expr.(*py.Num)
+	return ok && num.N == "0"
+}
+
+// isVar reports whether expr is the variable obj.
+func (c *Compiler) isVar(expr ast.Expr, obj types.Object) bool {
+	ident, ok := unparen(expr).(*ast.Ident)
+	return ok && obj != nil && c.Uses[ident] == obj
+}
+
+// isInvariant reports whether expr has the same value throughout the
+// function being compiled: it is a constant, a variable of the function
+// that is not assigned after its declaration, or the length of a string,
+// slice or array in such a variable.
+func (c *Compiler) isInvariant(expr ast.Expr) bool {
+	if tv, ok := c.Types[expr]; ok && tv.Value != nil {
+		return true
+	}
+	switch e := unparen(expr).(type) {
+	case *ast.Ident:
+		obj, ok := c.Uses[e].(*types.Var)
+		return ok && c.funcBody != nil && obj.Pos() >= c.funcType.Pos() && obj.Pos() < c.funcBody.End() &&
+			!c.addressed[obj] && !c.modifies(c.funcBody, obj)
+	case *ast.CallExpr:
+		if fun, ok := unparen(e.Fun).(*ast.Ident); ok && c.Uses[fun] == builtin.len {
+			switch c.TypeOf(e.Args[0]).Underlying().(type) {
+			case *types.Basic, *types.Slice, *types.Array:
+				return c.isInvariant(e.Args[0])
+			}
+		}
+	}
+	return false
+}
+
+// declareLoopVars finds the variables of a loop that function literals in
+// the loop capture: those among idents, which loop defines, and those
+// declared in its body. Each iteration has its own variables, so function
+// literals bind them when they are created. The variables that body assigns
+// are boxed so that the function literals share them with the rest of the
+// iteration. Before Go 1.22 the iterations share the variables that loop
+// defines.
+func (c *Compiler) declareLoopVars(loop ast.Stmt, body *ast.BlockStmt, idents []*ast.Ident) {
+	var vars []types.Object
+	if c.loopVarPerIteration() {
+		for _, ident := range idents {
+			vars = append(vars, c.Defs[ident])
+		}
+	}
+	ast.Inspect(body, func(n ast.Node) bool {
+		switch n := n.(type) {
+		case *ast.FuncLit:
+			return false
+		case *ast.Ident:
+			vars = append(vars, c.Defs[n])
+		case *ast.CaseClause:
+			vars = append(vars, c.Implicits[n])
+		}
+		return true
+	})
+	for _, obj := range vars {
+		if _, ok := obj.(*types.Var); !ok || !c.isCaptured(loop, obj) {
+			continue
+		}
+		c.captured[obj] = true
+		if c.modifies(body, obj) {
+			c.addressed[obj] = true
+		}
+	}
+}
+
+// isCaptured reports whether a function literal in node refers to obj.
+func (c *Compiler) isCaptured(node ast.Node, obj types.Object) bool {
+	captured := false
+	ast.Inspect(node, func(n ast.Node) bool {
+		if lit, ok := n.(*ast.FuncLit); ok {
+			ast.Inspect(lit.Body, func(n ast.Node) bool {
+				if ident, ok := n.(*ast.Ident); ok && c.Uses[ident] == obj {
+					captured = true
+				}
+				return !captured
+			})
+		}
+		return !captured
+	})
+	return captured
+}
+
+// renewLoopVars starts the next iteration of a for loop with copies of the
+// variables among idents that may be referred to after the iteration ends.
+func (c *Compiler) renewLoopVars(idents []*ast.Ident) []py.Stmt {
+	if !c.loopVarPerIteration() {
+		return nil
+	}
+	var stmts []py.Stmt
+	for _, ident := range idents {
+		obj := c.Defs[ident]
+		if obj == nil || !c.addressed[obj] {
+			continue
+		}
+		name := &py.Name{Id: c.objID(obj)}
+		var value py.Expr
+		if c.isBoxed(obj) {
+			value = runtimeCall("Box", &py.Attribute{Value: name, Attr: py.Identifier("value")})
+		} else {
+			value = c.copyValue(name, obj.Type())
+		}
+		stmts = append(stmts, &py.Assign{Targets: []py.Expr{name}, Value: value})
+	}
 	return stmts
 }
 
@@ -369,26 +940,9 @@ func (c *Compiler) compileExprToStmt(e ast.Expr) []py.Stmt {
 	case *ast.CallExpr:
 		switch fun := e.Fun.(type) {
 		case *ast.Ident:
-			switch fun.Name {
-			case "delete":
-				stmt = &py.Try{
-					Body: []py.Stmt{
-						&py.Delete{
-							Targets: []py.Expr{
-								&py.Subscript{
-									Value: ec.compileExpr(e.Args[0]),
-									Slice: &py.Index{ec.compileExpr(e.Args[1])},
-								},
-							},
-						},
-					},
-					Handlers: []py.ExceptHandler{
-						py.ExceptHandler{
-							Typ:  pyKeyError,
-							Body: []py.Stmt{&py.Pass{}},
-						},
-					},
-				}
+			switch c.ObjectOf(fun) {
+			case builtin.panic:
+				stmt = &py.Raise{Exc: runtimeCall("GoPanic", ec.compileValue(e.Args[0], emptyInterface))}
 			}
 		}
 	}
@@ -400,8 +954,76 @@ func (c *Compiler) compileExprToStmt(e ast.Expr) []py.Stmt {
 
 func (c *Compiler) compileReturnStmt(s *ast.ReturnStmt) []py.Stmt {
 	e := c.exprCompiler()
-	stmt := &py.Return{Value: e.compileExprsTuple(s.Results)}
-	return append(e.stmts, stmt)
+	if len(s.Results) == 0 && c.namedResults != nil {
+		if c.deferredResults {
+			return c.returnAfterDefers()
+		}
+		return c.returnValue(c.namedResultValues())
+	}
+	var results []py.Expr
+	if len(s.Results) == len(c.results) {
+		results = e.compileInOrder(s.Results, func(i int, result ast.Expr) py.Expr {
+			return e.compileValue(result, c.results[i])
+		})
+	} else {
+		results = e.compileExprs(s.Results)
+	}
+	if c.deferredResults {
+		var targets []py.Expr
+		for _, ident := range c.namedResults {
+			target := c.namedResult(ident)
+			if name, ok := target.(*py.Name); ok {
+				c.assigns(nil, name)
+			}
+			targets = append(targets, target)
+		}
+		assign := &py.Assign{Targets: targets, Value: makeTuple(results...)}
+		return append(append(e.stmts, assign), c.returnAfterDefers()...)
+	}
+	return append(e.stmts, c.returnValue(makeTuple(results...))...)
+}
+
+// returnValue returns value, or nothing if it is nil, from the function being
+// compiled. The body of a range-over-func loop stores the value and breaks
+// the loop, and the function returns it after the loop.
+func (c *Compiler) returnValue(value py.Expr) []py.Stmt {
+	inFunc := false
+	for _, l := range c.loops {
+		if l.isFunc {
+			l.returns = true
+			inFunc = true
+		}
+	}
+	if !inFunc {
+		return []py.Stmt{&py.Return{Value: value}}
+	}
+	if c.returned == nil {
+		c.returned = &py.Name{Id: c.tempID("returned")}
+	}
+	var stmts []py.Stmt
+	if value != nil {
+		if c.result == nil {
+			c.result = &py.Name{Id: c.tempID("result")}
+		}
+		c.assigns(nil, c.result)
+		stmts = append(stmts, &py.Assign{Targets: []py.Expr{c.result}, Value: value})
+	}
+	c.assigns(nil, c.returned)
+	return append(stmts,
+		&py.Assign{Targets: []py.Expr{c.returned}, Value: pyTrue},
+		&py.Return{Value: pyFalse},
+	)
+}
+
+// returnAfterDefers compiles a return from a function whose deferred calls
+// may modify its named results. The return leaves the body so that the
+// function returns the results once the deferred calls have run.
+func (c *Compiler) returnAfterDefers() []py.Stmt {
+	if c.returnLoop == nil {
+		// The return statement ends the body
+		return nil
+	}
+	return c.branch(c.returnLoop, false)
 }
 
 func (c *Compiler) compileExprStmt(s *ast.ExprStmt) []py.Stmt {
@@ -429,9 +1051,130 @@ func appendToList(list py.Expr, item py.Expr) py.Stmt {
 
 func (c *Compiler) compileDeferStmt(s *ast.DeferStmt) []py.Stmt {
 	e := c.exprCompiler()
-	f := e.compileExpr(s.Call.Fun)
-	args := &py.Tuple{Elts: e.compileExprs(s.Call.Args)}
-	return append(e.stmts, appendToList(c.defers, makeTuple(f, args)))
+	f, args := e.compileDeferredCall(s.Call)
+	return append(e.stmts, appendToList(c.defers, makeTuple(f, &py.Tuple{Elts: args})))
+}
+
+// compileGoStmt starts the call on a new goroutine. The function value and
+// arguments are evaluated by the calling goroutine, as in Go.
+func (c *Compiler) compileGoStmt(s *ast.GoStmt) []py.Stmt {
+	e := c.exprCompiler()
+	f, args := e.compileDeferredCall(s.Call)
+	return append(e.stmts, &py.ExprStmt{Value: runtimeCall("go", append([]py.Expr{f}, args...)...)})
+}
+
+// compileDeferredCall compiles the function value and arguments of the call
+// of a defer or go statement. Built-in functions are not Python values, so
+// a call of one is compiled to the call of the Python function that
+// implements it.
+func (c *exprCompiler) compileDeferredCall(call *ast.CallExpr) (py.Expr, []py.Expr) {
+	if c.Types[call.Fun].IsBuiltin() {
+		if compiled, ok := c.compileExpr(call).(*py.Call); ok && len(compiled.Keywords) == 0 {
+			return compiled.Func, compiled.Args
+		}
+		panic(c.err(call, "cannot defer or go %s", call.Fun))
+	}
+	f := c.compileExpr(call.Fun)
+	args := c.compileArgsAfter(call, call.Fun, &f)
+	return f, args
+}
+
+func (c *Compiler) compileSendStmt(s *ast.SendStmt) []py.Stmt {
+	e := c.exprCompiler()
+	elem := c.TypeOf(s.Chan).Underlying().(*types.Chan).Elem()
+	operands := e.compileInOrder([]ast.Expr{s.Chan, s.Value}, func(i int, operand ast.Expr) py.Expr {
+		if i == 0 {
+			return e.compileExpr(operand)
+		}
+		return e.compileValue(operand, elem)
+	})
+	send := methodCall(operands[0], "send", operands[1])
+	return append(e.stmts, &py.ExprStmt{Value: send})
+}
+
+// compileSelectStmt compiles a select statement to a call to runtime.select,
+// which chooses a case and returns its index, followed by an if statement
+// that runs the body of the chosen case.
+func (c *Compiler) compileSelectStmt(s *ast.SelectStmt) []py.Stmt {
+	e := c.exprCompiler()
+	blank := &py.Name{Id: py.Identifier("_")}
+	sel := &py.Name{Id: c.tempID("sel")}
+	recv, recvOk := blank, blank
+
+	var cases []py.Expr
+	var firstIfStmt *py.If
+	var lastIfStmt *py.If
+	var defaultBody []py.Stmt
+	hasDefault := false
+	for _, stmt := range s.Body.List {
+		clause := stmt.(*ast.CommClause)
+		var body []py.Stmt
+		switch comm := clause.Comm.(type) {
+		case nil:
+			hasDefault = true
+			defaultBody = c.compileStmts(clause.Body)
+			continue
+		case *ast.SendStmt:
+			elem := c.TypeOf(comm.Chan).Underlying().(*types.Chan).Elem()
+			cases = append(cases, runtimeCall("selectSend", e.compileExpr(comm.Chan), e.compileValue(comm.Value, elem)))
+		case *ast.ExprStmt:
+			ch := unparen(comm.X).(*ast.UnaryExpr).X
+			cases = append(cases, runtimeCall("selectRecv", e.compileExpr(ch)))
+		case *ast.AssignStmt:
+			ch := unparen(comm.Rhs[0]).(*ast.UnaryExpr).X
+			cases = append(cases, runtimeCall("selectRecv", e.compileExpr(ch)))
+			if recv == blank {
+				recv = &py.Name{Id: c.tempID("recv")}
+			}
+			var value py.Expr = recv
+			if len(comm.Lhs) == 2 {
+				if recvOk == blank {
+					recvOk = &py.Name{Id: c.tempID("recvOk")}
+				}
+				value = makeTuple(recv, recvOk)
+			}
+			// The targets are evaluated only if this case is chosen.
+			lhs := c.exprCompiler()
+			targets := lhs.compileTargets(comm.Lhs)
+			body = append(lhs.stmts, &py.Assign{Targets: targets, Value: value})
+		default:
+			panic(c.err(clause, "unknown CommClause: %T", comm))
+		}
+		body = append(body, c.compileStmts(clause.Body)...)
+		if len(body) == 0 {
+			body = []py.Stmt{&py.Pass{}}
+		}
+		test := &py.Compare{
+			Left:        sel,
+			Ops:         []py.CmpOp{py.Eq},
+			Comparators: []py.Expr{&py.Num{N: strconv.Itoa(len(cases) - 1)}},
+		}
+		ifStmt := &py.If{Test: test, Body: body}
+		if firstIfStmt == nil {
+			firstIfStmt = ifStmt
+		} else {
+			lastIfStmt.Orelse = []py.Stmt{ifStmt}
+		}
+		lastIfStmt = ifStmt
+	}
+
+	if len(cases) == 0 && hasDefault {
+		// A select with only a default case never blocks.
+		return defaultBody
+	}
+	block := pyTrue
+	if hasDefault {
+		block = pyFalse
+	}
+	stmts := append(e.stmts, &py.Assign{
+		Targets: []py.Expr{sel, recv, recvOk},
+		Value:   runtimeCall("select", &py.List{Elts: cases}, block),
+	})
+	if firstIfStmt != nil {
+		lastIfStmt.Orelse = defaultBody
+		stmts = append(stmts, firstIfStmt)
+	}
+	return stmts
 }
 
 func (c *Compiler) compileStmt(stmt ast.Stmt) []py.Stmt {
@@ -456,17 +1199,23 @@ func (c *Compiler) compileStmt(stmt ast.Stmt) []py.Stmt {
 	case *ast.DeclStmt:
 		pyStmts = c.compileDeclStmt(s)
 	case *ast.SwitchStmt:
-		pyStmts = c.compileSwitchStmt(s)
+		pyStmts = c.compileBreakable(s, func() []py.Stmt { return c.compileSwitchStmt(s) })
 	case *ast.TypeSwitchStmt:
-		pyStmts = c.compileTypeSwitchStmt(s)
+		pyStmts = c.compileBreakable(s, func() []py.Stmt { return c.compileTypeSwitchStmt(s) })
 	case *ast.BranchStmt:
 		pyStmts = c.compileBranchStmt(s)
 	case *ast.EmptyStmt:
 		pyStmts = []py.Stmt{}
 	case *ast.DeferStmt:
 		pyStmts = c.compileDeferStmt(s)
+	case *ast.GoStmt:
+		pyStmts = c.compileGoStmt(s)
+	case *ast.SendStmt:
+		pyStmts = c.compileSendStmt(s)
+	case *ast.SelectStmt:
+		pyStmts = c.compileBreakable(s, func() []py.Stmt { return c.compileSelectStmt(s) })
 	case *ast.LabeledStmt:
-		// TODO labels
+		// Labels are resolved by collectLabels
 		pyStmts = c.compileStmt(s.Stmt)
 	default:
 		panic(c.err(stmt, "unknown Stmt: %T", stmt))
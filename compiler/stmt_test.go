@@ -26,8 +26,14 @@ var (
 	w, x, y, z int
 	u0, u1 uint
 	xs []int
+	ts []T
+	arr [2]int
 	obj interface{}
 	m map[int]int
+	am map[[2]int]int
+	ch chan int
+	tch chan T
+	seq func(func(int, int) bool)
 )
 
 func ignore(interface{}) {}
@@ -49,6 +55,36 @@ const f = py.Identifier("f")
 // Name of temp variable used to store evaluated switch tag
 var tag = &py.Name{Id: py.Identifier("tag")}
 
+// Name of temp variable used to store the index of the matching clause of a
+// switch containing fallthrough
+var clause = &py.Name{Id: py.Identifier("clause")}
+
+// Names of temp variables used to compile goto and branches out of nested loops
+var (
+	label        = &py.Name{Id: py.Identifier("label")}
+	breakL       = &py.Name{Id: py.Identifier("breakL")}
+	continueL    = &py.Name{Id: py.Identifier("continueL")}
+	jump         = &py.Name{Id: py.Identifier("jump")}
+	breakLoop    = &py.Name{Id: py.Identifier("breakLoop")}
+	continueLoop = &py.Name{Id: py.Identifier("continueLoop")}
+)
+
+// labelLtE tests whether the goto block state is at most segment i
+func labelLtE(i int) py.Expr {
+	return &py.Compare{Left: label, Ops: []py.CmpOp{py.LtE}, Comparators: []py.Expr{&py.Num{N: strconv.Itoa(i)}}}
+}
+
+// Names of temp variables used to store the result of a select
+var (
+	sel    = &py.Name{Id: py.Identifier("sel")}
+	recv   = &py.Name{Id: py.Identifier("recv")}
+	recvOk = &py.Name{Id: py.Identifier("recvOk")}
+	blank  = &py.Name{Id: py.Identifier("_")}
+)
+
+// Iterator function of range-over-func loops
+var seq = &py.Name{Id: py.Identifier("seq")}
+
 // Var decl targets e.g. ax := 0; var ax int; const ax = 0
 var (
 	ax = &py.Name{Id: py.Identifier("ax")}
@@ -73,7 +109,15 @@ func s(is ...interface{}) []py.Stmt {
 			panic(fmt.Sprintf("%T", i))
 		}
 	}
-	return []py.Stmt{&py.ExprStmt{Value: &py.Call{Func: &py.Name{Id: py.Identifier("s")}, Args: args}}}
+	// s is variadic so its arguments are passed as a slice
+	var slice py.Expr = &py.Attribute{Value: runtimeModule, Attr: py.Identifier("nilSlice")}
+	if len(args) > 0 {
+		slice = &py.Call{
+			Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("Slice")},
+			Args: []py.Expr{&py.List{Elts: args}},
+		}
+	}
+	return []py.Stmt{&py.ExprStmt{Value: &py.Call{Func: &py.Name{Id: py.Identifier("s")}, Args: []py.Expr{slice}}}}
 }
 
 var (
@@ -93,8 +137,23 @@ var stmtTests = []struct {
 	{"ignore(x)", []py.Stmt{&py.ExprStmt{Value: &py.Call{Func: ignore, Args: []py.Expr{x}}}}},
 
 	// IncDec statements
-	{"x++", []py.Stmt{&py.AugAssign{Target: x, Op: py.Add, Value: one}}},
-	{"x--", []py.Stmt{&py.AugAssign{Target: x, Op: py.Sub, Value: one}}},
+	{"x++", []py.Stmt{&py.Assign{Targets: []py.Expr{x}, Value: wrapInt("int", &py.BinOp{Left: x, Op: py.Add, Right: one})}}},
+	{"x--", []py.Stmt{&py.Assign{Targets: []py.Expr{x}, Value: wrapInt("int", &py.BinOp{Left: x, Op: py.Sub, Right: one})}}},
+	{"xs[f0()]++", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("t")}}, Value: &py.Call{Func: f0}},
+		&py.Assign{
+			Targets: []py.Expr{&py.Subscript{Value: xs, Slice: &py.Index{Value: &py.Name{Id: py.Identifier("t")}}}},
+			Value: wrapInt("int", &py.BinOp{
+				Left:  &py.Subscript{Value: xs, Slice: &py.Index{Value: &py.Name{Id: py.Identifier("t")}}},
+				Op:    py.Add,
+				Right: one,
+			}),
+		},
+	}},
+	{"t0.x++", []py.Stmt{&py.Assign{
+		Targets: []py.Expr{&py.Attribute{Value: t0, Attr: x.Id}},
+		Value:   wrapInt("int", &py.BinOp{Left: &py.Attribute{Value: t0, Attr: x.Id}, Op: py.Add, Right: one}),
+	}}},
 
 	// Assignments
 	{"x = y", []py.Stmt{&py.Assign{Targets: []py.Expr{x}, Value: y}}},
@@ -102,6 +161,11 @@ var stmtTests = []struct {
 		Targets: []py.Expr{x, y},
 		Value:   &py.Call{Func: g2},
 	}}},
+	{"xs = nil", []py.Stmt{&py.Assign{Targets: []py.Expr{xs}, Value: nilSlice}}},
+	{"xs, obj = nil, nil", []py.Stmt{&py.Assign{
+		Targets: []py.Expr{xs, obj},
+		Value:   &py.Tuple{Elts: []py.Expr{nilSlice, pyNone}},
+	}}},
 	{"x, y = y, x", []py.Stmt{&py.Assign{
 		Targets: []py.Expr{x, y},
 		Value:   &py.Tuple{Elts: []py.Expr{y, x}},
@@ -118,15 +182,125 @@ var stmtTests = []struct {
 		Value:   &py.Tuple{Elts: []py.Expr{y, x}},
 	}}},
 
+	// Variables whose address is taken
+	{"{ax := 0; ay := &ax; *ay = 1}", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{ax}, Value: callRuntime("Box", &py.Num{N: "0"})},
+		&py.Assign{Targets: []py.Expr{ay}, Value: ax},
+		&py.Assign{Targets: []py.Expr{&py.Attribute{Value: ay, Attr: py.Identifier("value")}}, Value: &py.Num{N: "1"}},
+	}},
+	{"{ax, ay := g2(); _, _ = &ax, ay}", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{ax, ay}, Value: &py.Call{Func: g2}},
+		&py.Assign{Targets: []py.Expr{ax}, Value: callRuntime("Box", ax)},
+		&py.Assign{Targets: []py.Expr{blank, blank}, Value: &py.Tuple{Elts: []py.Expr{ax, ay}}},
+	}},
+	{"{var ax int; ay := &ax; ax = *ay}", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{ax}, Value: callRuntime("Box", &py.Num{N: "0"})},
+		&py.Assign{Targets: []py.Expr{ay}, Value: ax},
+		&py.Assign{
+			Targets: []py.Expr{&py.Attribute{Value: ax, Attr: py.Identifier("value")}},
+			Value:   &py.Attribute{Value: ay, Attr: py.Identifier("value")},
+		},
+	}},
+	{"{ax := t0; ay := &ax; *ay = t1}", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{ax}, Value: copyOf(t0)},
+		&py.Assign{Targets: []py.Expr{ay}, Value: ax},
+		&py.ExprStmt{Value: callRuntime("store", ay, copyOf(t1))},
+	}},
+	{"{ax := t0; _ = &ax; ax = T{}}", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{ax}, Value: copyOf(t0)},
+		&py.Assign{Targets: []py.Expr{blank}, Value: ax},
+		&py.ExprStmt{Value: callRuntime("store", ax, &py.Call{Func: T})},
+	}},
+	{"for _, ax := range xs { _ = &ax }", []py.Stmt{
+		&py.For{
+			Target: ax,
+			Iter:   xs,
+			Body: []py.Stmt{
+				&py.Assign{Targets: []py.Expr{ax}, Value: callRuntime("Box", ax)},
+				&py.Assign{Targets: []py.Expr{blank}, Value: ax},
+			},
+		},
+	}},
+
+	// Type assertion with comma-ok
+	{"x, b0 = obj.(int)", []py.Stmt{&py.Assign{
+		Targets: []py.Expr{x, b0},
+		Value: &py.Call{
+			Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("typeAssertOk")},
+			Args: []py.Expr{obj, &py.Attribute{Value: runtimeModule, Attr: py.Identifier("int")}, &py.Num{N: "0"}},
+		},
+	}}},
+	{"ax, ay := obj.(error); _, _ = ax, ay", []py.Stmt{&py.Assign{
+		Targets: []py.Expr{ax, ay},
+		Value: &py.Call{
+			Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("typeAssertOk")},
+			Args: []py.Expr{obj, &py.Attribute{Value: runtimeModule, Attr: py.Identifier("error")}},
+		},
+	}}},
+
+	// Receive with comma-ok
+	{"x, b0 = <-ch", []py.Stmt{&py.Assign{
+		Targets: []py.Expr{x, b0},
+		Value:   &py.Call{Func: &py.Attribute{Value: ch, Attr: py.Identifier("recvOk")}},
+	}}},
+	{"ax, ay := <-ch; _, _ = ax, ay", []py.Stmt{&py.Assign{
+		Targets: []py.Expr{ax, ay},
+		Value:   &py.Call{Func: &py.Attribute{Value: ch, Attr: py.Identifier("recvOk")}},
+	}}},
+	{"var ax, ay = <-ch; _, _ = ax, ay", []py.Stmt{&py.Assign{
+		Targets: []py.Expr{ax, ay},
+		Value:   &py.Call{Func: &py.Attribute{Value: ch, Attr: py.Identifier("recvOk")}},
+	}}},
+
+	// Map lookup with comma-ok
+	{"x, b0 = m[y]", []py.Stmt{&py.Assign{
+		Targets: []py.Expr{x, b0},
+		Value:   callRuntime("mapIndexOk", m, y, zero),
+	}}},
+	{"var ax, ay = m[1]; _, _ = ax, ay", []py.Stmt{&py.Assign{
+		Targets: []py.Expr{ax, ay},
+		Value:   callRuntime("mapIndexOk", m, one, zero),
+	}}},
+
+	// Map entries are stored by subscript
+	{"m[x] = y", []py.Stmt{&py.Assign{
+		Targets: []py.Expr{&py.Subscript{Value: m, Slice: &py.Index{Value: x}}},
+		Value:   y,
+	}}},
+	{"m[x]++", []py.Stmt{&py.Assign{
+		Targets: []py.Expr{&py.Subscript{Value: m, Slice: &py.Index{Value: x}}},
+		Value:   wrapInt("int", &py.BinOp{Left: callRuntime("mapIndex", m, x, zero), Op: py.Add, Right: one}),
+	}}},
+	{"m[x] |= y", []py.Stmt{&py.Assign{
+		Targets: []py.Expr{&py.Subscript{Value: m, Slice: &py.Index{Value: x}}},
+		Value:   &py.BinOp{Left: callRuntime("mapIndex", m, x, zero), Op: py.BitOr, Right: y},
+	}}},
+
+	// Calls in the operands of targets are evaluated before the values
+	{"m[f1(x)], xs[f1(y)] = f1(z), f1(w)", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("t")}}, Value: &py.Call{Func: f1, Args: []py.Expr{x}}},
+		&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("t1")}}, Value: &py.Call{Func: f1, Args: []py.Expr{y}}},
+		&py.Assign{
+			Targets: []py.Expr{
+				&py.Subscript{Value: m, Slice: &py.Index{Value: &py.Name{Id: py.Identifier("t")}}},
+				&py.Subscript{Value: xs, Slice: &py.Index{Value: &py.Name{Id: py.Identifier("t1")}}},
+			},
+			Value: &py.Tuple{Elts: []py.Expr{&py.Call{Func: f1, Args: []py.Expr{z}}, &py.Call{Func: f1, Args: []py.Expr{w}}}},
+		},
+	}},
+
 	// Augmented assignments
-	{"x +=  y", []py.Stmt{&py.AugAssign{Op: py.Add, Target: x, Value: y}}},
-	{"x -=  y", []py.Stmt{&py.AugAssign{Op: py.Sub, Target: x, Value: y}}},
+	{"x +=  y", []py.Stmt{&py.Assign{Targets: []py.Expr{x}, Value: wrapInt("int", &py.BinOp{Left: x, Op: py.Add, Right: y})}}},
+	{"x -=  y", []py.Stmt{&py.Assign{Targets: []py.Expr{x}, Value: wrapInt("int", &py.BinOp{Left: x, Op: py.Sub, Right: y})}}},
 	{"x |=  y", []py.Stmt{&py.AugAssign{Op: py.BitOr, Target: x, Value: y}}},
 	{"x ^=  y", []py.Stmt{&py.AugAssign{Op: py.BitXor, Target: x, Value: y}}},
-	{"x *=  y", []py.Stmt{&py.AugAssign{Op: py.Mult, Target: x, Value: y}}},
-	{"x /=  y", []py.Stmt{&py.AugAssign{Op: py.FloorDiv, Target: x, Value: y}}}, // TODO py.Div for floats
-	{"x %=  y", []py.Stmt{&py.AugAssign{Op: py.Mod, Target: x, Value: y}}},
-	{"x <<= u0", []py.Stmt{&py.AugAssign{Op: py.LShift, Target: x, Value: u0}}},
+	{"x *=  y", []py.Stmt{&py.Assign{Targets: []py.Expr{x}, Value: wrapInt("int", &py.BinOp{Left: x, Op: py.Mult, Right: y})}}},
+	{"x /=  y", []py.Stmt{&py.Assign{Targets: []py.Expr{x}, Value: intMethod("int", "quo", x, y)}}},
+	{"x %=  y", []py.Stmt{&py.Assign{Targets: []py.Expr{x}, Value: intMethod("int", "rem", x, y)}}},
+	{"u0 /= u1", []py.Stmt{&py.AugAssign{Op: py.FloorDiv, Target: u0, Value: u1}}},
+	{"u0 %= u1", []py.Stmt{&py.AugAssign{Op: py.Mod, Target: u0, Value: u1}}},
+	{"x <<= u0", []py.Stmt{&py.Assign{Targets: []py.Expr{x}, Value: wrapInt("int", &py.BinOp{Left: x, Op: py.LShift, Right: u0})}}},
+	{"u0 -= u1", []py.Stmt{&py.Assign{Targets: []py.Expr{u0}, Value: wrapInt("uint", &py.BinOp{Left: u0, Op: py.Sub, Right: u1})}}},
 	{"x >>= u0", []py.Stmt{&py.AugAssign{Op: py.RShift, Target: x, Value: u0}}},
 	{"x &=  y", []py.Stmt{&py.AugAssign{Op: py.BitAnd, Target: x, Value: y}}},
 	{"x &^= y", []py.Stmt{&py.AugAssign{Op: py.BitAnd, Target: x, Value: &py.UnaryOpExpr{Op: py.Invert, Operand: y}}}},
@@ -183,6 +357,29 @@ var stmtTests = []struct {
 			Body:   s(x),
 		},
 	}},
+	{"for _, x := range ts {s(x)}", []py.Stmt{
+		// Struct elements are copied
+		&py.For{
+			Target: x,
+			Iter:   &py.Name{Id: py.Identifier("ts")},
+			Body:   append([]py.Stmt{&py.Assign{Targets: []py.Expr{x}, Value: copyOf(x)}}, s(iface(T, copyOf(x)))...),
+		},
+	}},
+	{"for _, x := range arr {s(x)}", []py.Stmt{
+		// The array is copied before the loop
+		&py.For{
+			Target: x,
+			Iter:   &py.Subscript{Value: arr, Slice: &py.RangeSlice{}},
+			Body:   s(x),
+		},
+	}},
+	{"for x := range arr {s(x)}", []py.Stmt{
+		&py.For{
+			Target: x,
+			Iter:   &py.Call{Func: pyRange, Args: []py.Expr{&py.Call{Func: pyLen, Args: []py.Expr{arr}}}},
+			Body:   s(x),
+		},
+	}},
 	{"for range xs {}", []py.Stmt{
 		&py.For{
 			Target: &py.Name{Id: py.Identifier("_")},
@@ -191,6 +388,133 @@ var stmtTests = []struct {
 		},
 	}},
 
+	{"for x := range ch {s(x)}", []py.Stmt{
+		// for x in ch: s
+		&py.For{
+			Target: x,
+			Iter:   ch,
+			Body:   s(x),
+		},
+	}},
+	{"for range ch {}", []py.Stmt{
+		&py.For{
+			Target: &py.Name{Id: py.Identifier("_")},
+			Iter:   ch,
+			Body:   []py.Stmt{&py.Pass{}},
+		},
+	}},
+	{"for x, y := range m {s(x, y)}", []py.Stmt{
+		// for x, y in runtime.rangeMap(m): s
+		&py.For{
+			Target: &py.Tuple{Elts: []py.Expr{x, y}},
+			Iter:   runtimeCall("rangeMap", m),
+			Body:   s(x, y),
+		},
+	}},
+	{"for x := range m {s(x)}", []py.Stmt{
+		&py.For{
+			Target: &py.Tuple{Elts: []py.Expr{x, blank}},
+			Iter:   runtimeCall("rangeMap", m),
+			Body:   s(x),
+		},
+	}},
+	{"for ax := range am { _ = ax }", []py.Stmt{
+		// Array keys are converted back from tuples
+		&py.For{
+			Target: &py.Tuple{Elts: []py.Expr{ax, blank}},
+			Iter:   runtimeCall("rangeMap", &py.Name{Id: py.Identifier("am")}, pyTrue),
+			Body:   []py.Stmt{&py.Assign{Targets: []py.Expr{blank}, Value: &py.Subscript{Value: ax, Slice: &py.RangeSlice{}}}},
+		},
+	}},
+	{"for x, y := range \"ab\" {s(x, y)}", []py.Stmt{
+		// Byte offsets and runes
+		&py.For{
+			Target: &py.Tuple{Elts: []py.Expr{x, y}},
+			Iter:   runtimeCall("rangeString", &py.Str{S: `"ab"`}),
+			Body:   s(x, iface(runtimeAttr("rune"), y)),
+		},
+	}},
+	{"for _, y := range \"ab\" {s(y)}", []py.Stmt{
+		&py.For{
+			Target: &py.Tuple{Elts: []py.Expr{blank, y}},
+			Iter:   runtimeCall("rangeString", &py.Str{S: `"ab"`}),
+			Body:   s(iface(runtimeAttr("rune"), y)),
+		},
+	}},
+	{"for x := range 2 {s(x)}", []py.Stmt{
+		&py.For{
+			Target: x,
+			Iter:   &py.Call{Func: pyRange, Args: []py.Expr{two}},
+			Body:   s(x),
+		},
+	}},
+	{"for range w {}", []py.Stmt{
+		&py.For{
+			Target: blank,
+			Iter:   &py.Call{Func: pyRange, Args: []py.Expr{w}},
+			Body:   []py.Stmt{&py.Pass{}},
+		},
+	}},
+
+	// Range over function: the body is a function that returns whether to continue
+	{"for x := range seq { if b0 { break }; s(x) }", []py.Stmt{
+		&py.FunctionDef{
+			Name: py.Identifier("body"),
+			Args: py.Arguments{Args: []py.Arg{{Arg: py.Identifier("x")}, {Arg: py.Identifier("_")}}},
+			Body: append(append(
+				[]py.Stmt{&py.If{Test: b0, Body: []py.Stmt{&py.Return{Value: pyFalse}}}},
+				s(x)...),
+				&py.Return{Value: pyTrue}),
+		},
+		&py.ExprStmt{Value: runtimeCall("rangeFunc", seq, &py.Name{Id: py.Identifier("body")})},
+	}},
+	{"for range seq { continue }", []py.Stmt{
+		&py.FunctionDef{
+			Name: py.Identifier("body"),
+			Args: py.Arguments{Vararg: &py.Arg{Arg: py.Identifier("_")}},
+			Body: []py.Stmt{&py.Return{Value: pyTrue}},
+		},
+		&py.ExprStmt{Value: runtimeCall("rangeFunc", seq, &py.Name{Id: py.Identifier("body")})},
+	}},
+	{"for x, _ = range seq { s(x) }", []py.Stmt{
+		// Package variables assigned by the body are global
+		&py.FunctionDef{
+			Name: py.Identifier("body"),
+			Args: py.Arguments{Args: []py.Arg{{Arg: py.Identifier("arg")}, {Arg: py.Identifier("_")}}},
+			Body: append(append(
+				[]py.Stmt{
+					&py.Global{Names: []py.Identifier{"x"}},
+					&py.Assign{Targets: []py.Expr{x}, Value: &py.Name{Id: py.Identifier("arg")}},
+				},
+				s(x)...),
+				&py.Return{Value: pyTrue}),
+		},
+		&py.ExprStmt{Value: runtimeCall("rangeFunc", seq, &py.Name{Id: py.Identifier("body")})},
+	}},
+	{"L: for range xs { for range seq { continue L } }", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{continueL}, Value: pyFalse},
+		&py.For{
+			Target: blank,
+			Iter:   xs,
+			Body: []py.Stmt{
+				&py.FunctionDef{
+					Name: py.Identifier("body"),
+					Args: py.Arguments{Vararg: &py.Arg{Arg: py.Identifier("_")}},
+					Body: []py.Stmt{
+						&py.Nonlocal{Names: []py.Identifier{"continueL"}},
+						&py.Assign{Targets: []py.Expr{continueL}, Value: pyTrue},
+						&py.Return{Value: pyFalse},
+					},
+				},
+				&py.ExprStmt{Value: runtimeCall("rangeFunc", seq, &py.Name{Id: py.Identifier("body")})},
+				&py.If{Test: continueL, Body: []py.Stmt{
+					&py.Assign{Targets: []py.Expr{continueL}, Value: pyFalse},
+					&py.Continue{},
+				}},
+			},
+		},
+	}},
+
 	// For statement
 	{"for {s(0)}", []py.Stmt{
 		&py.While{
@@ -217,6 +541,105 @@ var stmtTests = []struct {
 				Body: append(s(2), s(1)...),
 			}),
 	},
+	{"for s(0); b0; s(1) {continue}",
+		append(s(0),
+			&py.While{
+				Test: b0,
+				Body: append(s(1), &py.Continue{}),
+			}),
+	},
+	{"for i := 0; i < 2; i += 2 { if b0 { continue }; s(i) }", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{i}, Value: zero},
+		&py.While{
+			Test: &py.Compare{Left: i, Ops: []py.CmpOp{py.Lt}, Comparators: []py.Expr{two}},
+			Body: []py.Stmt{
+				&py.If{Test: b0, Body: []py.Stmt{
+					&py.Assign{Targets: []py.Expr{i}, Value: callRuntime("int", &py.BinOp{Left: i, Op: py.Add, Right: two})},
+					&py.Continue{},
+				}},
+				s(i)[0],
+				&py.Assign{Targets: []py.Expr{i}, Value: callRuntime("int", &py.BinOp{Left: i, Op: py.Add, Right: two})},
+			},
+		},
+	}},
+	{"L: for i := 0; i < 2; i += 2 { for { continue L } }", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{i}, Value: zero},
+		&py.Assign{Targets: []py.Expr{continueL}, Value: pyFalse},
+		&py.While{
+			Test: &py.Compare{Left: i, Ops: []py.CmpOp{py.Lt}, Comparators: []py.Expr{two}},
+			Body: []py.Stmt{
+				&py.While{Test: pyTrue, Body: []py.Stmt{
+					&py.Assign{Targets: []py.Expr{continueL}, Value: pyTrue},
+					&py.Break{},
+				}},
+				&py.If{Test: continueL, Body: []py.Stmt{
+					&py.Assign{Targets: []py.Expr{continueL}, Value: pyFalse},
+					&py.Assign{Targets: []py.Expr{i}, Value: callRuntime("int", &py.BinOp{Left: i, Op: py.Add, Right: two})},
+					&py.Continue{},
+				}},
+				&py.Assign{Targets: []py.Expr{i}, Value: callRuntime("int", &py.BinOp{Left: i, Op: py.Add, Right: two})},
+			},
+		},
+	}},
+
+	// Statements hoisted from the condition run on every iteration
+	{"for func() bool { return b0 }() {}", []py.Stmt{
+		&py.While{Test: pyTrue, Body: []py.Stmt{
+			&py.FunctionDef{Name: py.Identifier("func"), Body: []py.Stmt{&py.Return{Value: b0}}},
+			&py.If{
+				Test: &py.UnaryOpExpr{Op: py.Not, Operand: &py.Call{Func: &py.Name{Id: py.Identifier("func")}}},
+				Body: []py.Stmt{&py.Break{}},
+			},
+		}},
+	}},
+
+	// Counting loops are Python for statements over a range
+	{"for i := 0; i < 2; i++ { s(i) }", []py.Stmt{
+		&py.For{Target: i, Iter: &py.Call{Func: pyRange, Args: []py.Expr{two}}, Body: s(i)},
+	}},
+	{"for i := 1; i < 2; i++ { if b0 { continue } }", []py.Stmt{
+		&py.For{
+			Target: i,
+			Iter:   &py.Call{Func: pyRange, Args: []py.Expr{one, two}},
+			Body:   []py.Stmt{&py.If{Test: b0, Body: []py.Stmt{&py.Continue{}}}},
+		},
+	}},
+	{"for i := 0; i < x; i++ {}", []py.Stmt{
+		// x is a package variable, which may change as the loop runs
+		&py.Assign{Targets: []py.Expr{i}, Value: zero},
+		&py.While{
+			Test: &py.Compare{Left: i, Ops: []py.CmpOp{py.Lt}, Comparators: []py.Expr{x}},
+			Body: []py.Stmt{
+				&py.Assign{Targets: []py.Expr{i}, Value: callRuntime("int", &py.BinOp{Left: i, Op: py.Add, Right: one})},
+			},
+		},
+	}},
+	{"for i := 0; i < 2; i++ { i++ }", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{i}, Value: zero},
+		&py.While{
+			Test: &py.Compare{Left: i, Ops: []py.CmpOp{py.Lt}, Comparators: []py.Expr{two}},
+			Body: []py.Stmt{
+				&py.Assign{Targets: []py.Expr{i}, Value: callRuntime("int", &py.BinOp{Left: i, Op: py.Add, Right: one})},
+				&py.Assign{Targets: []py.Expr{i}, Value: callRuntime("int", &py.BinOp{Left: i, Op: py.Add, Right: one})},
+			},
+		},
+	}},
+
+	// Statements hoisted from a case run only if the cases before it do not match
+	{"switch x { case 1: s(0); case func() int { return 2 }(): s(1) }", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{tag}, Value: x},
+		&py.If{
+			Test: &py.Compare{Left: tag, Ops: []py.CmpOp{py.Eq}, Comparators: []py.Expr{one}},
+			Body: s(0),
+			Orelse: []py.Stmt{
+				&py.FunctionDef{Name: py.Identifier("func"), Body: []py.Stmt{&py.Return{Value: two}}},
+				&py.If{
+					Test: &py.Compare{Left: tag, Ops: []py.CmpOp{py.Eq}, Comparators: []py.Expr{&py.Call{Func: &py.Name{Id: py.Identifier("func")}}}},
+					Body: s(1),
+				},
+			},
+		},
+	}},
 
 	// Var declaration statements
 	{"var ax int; _ = ax", []py.Stmt{
@@ -252,7 +675,7 @@ var stmtTests = []struct {
 	{"var ax []T; _ = ax", []py.Stmt{
 		&py.Assign{
 			Targets: []py.Expr{ax},
-			Value:   pyNone,
+			Value:   nilSlice,
 		},
 	}},
 	{"var ax, ay int; _, _ = ax, ay", []py.Stmt{
@@ -320,35 +743,207 @@ var stmtTests = []struct {
 	{"type T struct {}", []py.Stmt{
 		&py.ClassDef{
 			Name: T.Id,
-			Body: []py.Stmt{&py.Pass{}},
+			Body: []py.Stmt{
+				&py.FunctionDef{
+					Name: py.Identifier("__copy__"),
+					Args: py.Arguments{Args: []py.Arg{py.Arg{Arg: pySelf}}},
+					Body: []py.Stmt{&py.Return{Value: &py.Call{Func: T}}},
+				},
+				&py.FunctionDef{
+					Name: py.Identifier("__eq__"),
+					Args: py.Arguments{Args: []py.Arg{py.Arg{Arg: pySelf}, py.Arg{Arg: py.Identifier("other")}}},
+					Body: []py.Stmt{&py.Return{Value: pyTrue}},
+				},
+				&py.FunctionDef{
+					Name: py.Identifier("__hash__"),
+					Args: py.Arguments{Args: []py.Arg{py.Arg{Arg: pySelf}}},
+					Body: []py.Stmt{&py.Return{Value: &py.Call{Func: pyHash, Args: []py.Expr{&py.Tuple{}}}}},
+				},
+			},
 		},
 	}},
 	{"type T struct { x U }", []py.Stmt{
 		&py.ClassDef{
 			Name: T.Id,
-			Body: []py.Stmt{&py.FunctionDef{
-				Name: py.Identifier("__init__"),
-				Args: py.Arguments{
-					Args: []py.Arg{
-						py.Arg{Arg: pySelf},
-						py.Arg{Arg: x.Id},
+			Body: []py.Stmt{
+				&py.FunctionDef{
+					Name: py.Identifier("__init__"),
+					Args: py.Arguments{
+						Args: []py.Arg{
+							py.Arg{Arg: pySelf},
+							py.Arg{Arg: x.Id},
+						},
+						Defaults: []py.Expr{pyNone},
 					},
-					Defaults: []py.Expr{
-						&py.Call{Func: U},
+					Body: []py.Stmt{
+						&py.If{
+							Test: &py.Compare{Left: x, Ops: []py.CmpOp{py.Is}, Comparators: []py.Expr{pyNone}},
+							Body: []py.Stmt{&py.Assign{Targets: []py.Expr{x}, Value: &py.Call{Func: U}}},
+						},
+						&py.Assign{
+							Targets: []py.Expr{
+								&py.Attribute{
+									Value: &py.Name{Id: pySelf},
+									Attr:  x.Id,
+								},
+							},
+							Value: x,
+						},
 					},
 				},
-				Body: []py.Stmt{
-					&py.Assign{
-						Targets: []py.Expr{
-							&py.Attribute{
-								Value: &py.Name{Id: pySelf},
-								Attr:  x.Id,
-							},
+				&py.FunctionDef{
+					Name: py.Identifier("__copy__"),
+					Args: py.Arguments{Args: []py.Arg{py.Arg{Arg: pySelf}}},
+					Body: []py.Stmt{&py.Return{Value: &py.Call{
+						Func: T,
+						Args: []py.Expr{copyOf(&py.Attribute{Value: &py.Name{Id: pySelf}, Attr: x.Id})},
+					}}},
+				},
+				&py.FunctionDef{
+					Name: py.Identifier("__eq__"),
+					Args: py.Arguments{Args: []py.Arg{py.Arg{Arg: pySelf}, py.Arg{Arg: py.Identifier("other")}}},
+					Body: []py.Stmt{&py.Return{Value: &py.Compare{
+						Left:        &py.Attribute{Value: &py.Name{Id: pySelf}, Attr: x.Id},
+						Ops:         []py.CmpOp{py.Eq},
+						Comparators: []py.Expr{&py.Attribute{Value: &py.Name{Id: py.Identifier("other")}, Attr: x.Id}},
+					}}},
+				},
+				&py.FunctionDef{
+					Name: py.Identifier("__hash__"),
+					Args: py.Arguments{Args: []py.Arg{py.Arg{Arg: pySelf}}},
+					Body: []py.Stmt{&py.Return{Value: &py.Call{
+						Func: pyHash,
+						Args: []py.Expr{&py.Tuple{Elts: []py.Expr{&py.Attribute{Value: &py.Name{Id: pySelf}, Attr: x.Id}}}},
+					}}},
+				},
+			},
+		},
+	}},
+	{"type V struct { U U }", []py.Stmt{
+		// The parameter for field U must not hide the class U
+		&py.ClassDef{
+			Name: py.Identifier("V"),
+			Body: []py.Stmt{
+				&py.FunctionDef{
+					Name: py.Identifier("__init__"),
+					Args: py.Arguments{
+						Args:     []py.Arg{{Arg: pySelf}, {Arg: py.Identifier("U_")}},
+						Defaults: []py.Expr{pyNone},
+					},
+					Body: []py.Stmt{
+						&py.If{
+							Test: &py.Compare{Left: &py.Name{Id: py.Identifier("U_")}, Ops: []py.CmpOp{py.Is}, Comparators: []py.Expr{pyNone}},
+							Body: []py.Stmt{&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("U_")}}, Value: &py.Call{Func: U}}},
+						},
+						&py.Assign{
+							Targets: []py.Expr{&py.Attribute{Value: &py.Name{Id: pySelf}, Attr: U.Id}},
+							Value:   &py.Name{Id: py.Identifier("U_")},
 						},
-						Value: x,
 					},
 				},
-			}},
+				&py.FunctionDef{
+					Name: py.Identifier("__copy__"),
+					Args: py.Arguments{Args: []py.Arg{py.Arg{Arg: pySelf}}},
+					Body: []py.Stmt{&py.Return{Value: &py.Call{
+						Func: &py.Name{Id: py.Identifier("V")},
+						Args: []py.Expr{copyOf(&py.Attribute{Value: &py.Name{Id: pySelf}, Attr: U.Id})},
+					}}},
+				},
+				&py.FunctionDef{
+					Name: py.Identifier("__eq__"),
+					Args: py.Arguments{Args: []py.Arg{py.Arg{Arg: pySelf}, py.Arg{Arg: py.Identifier("other")}}},
+					Body: []py.Stmt{&py.Return{Value: &py.Compare{
+						Left:        &py.Attribute{Value: &py.Name{Id: pySelf}, Attr: U.Id},
+						Ops:         []py.CmpOp{py.Eq},
+						Comparators: []py.Expr{&py.Attribute{Value: &py.Name{Id: py.Identifier("other")}, Attr: U.Id}},
+					}}},
+				},
+				&py.FunctionDef{
+					Name: py.Identifier("__hash__"),
+					Args: py.Arguments{Args: []py.Arg{py.Arg{Arg: pySelf}}},
+					Body: []py.Stmt{&py.Return{Value: &py.Call{
+						Func: pyHash,
+						Args: []py.Expr{&py.Tuple{Elts: []py.Expr{&py.Attribute{Value: &py.Name{Id: pySelf}, Attr: U.Id}}}},
+					}}},
+				},
+			},
+		},
+	}},
+	{"{ type list struct{}; var l list; _ = l }", []py.Stmt{
+		// The class is renamed so that it does not hide the Python builtin
+		&py.ClassDef{
+			Name: py.Identifier("list1"),
+			Body: []py.Stmt{
+				&py.FunctionDef{
+					Name: py.Identifier("__copy__"),
+					Args: py.Arguments{Args: []py.Arg{py.Arg{Arg: pySelf}}},
+					Body: []py.Stmt{&py.Return{Value: &py.Call{Func: &py.Name{Id: py.Identifier("list1")}}}},
+				},
+				&py.FunctionDef{
+					Name: py.Identifier("__eq__"),
+					Args: py.Arguments{Args: []py.Arg{py.Arg{Arg: pySelf}, py.Arg{Arg: py.Identifier("other")}}},
+					Body: []py.Stmt{&py.Return{Value: pyTrue}},
+				},
+				&py.FunctionDef{
+					Name: py.Identifier("__hash__"),
+					Args: py.Arguments{Args: []py.Arg{py.Arg{Arg: pySelf}}},
+					Body: []py.Stmt{&py.Return{Value: &py.Call{Func: pyHash, Args: []py.Expr{&py.Tuple{}}}}},
+				},
+			},
+		},
+		&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("l")}}, Value: &py.Call{Func: &py.Name{Id: py.Identifier("list1")}}},
+		&py.Assign{Targets: []py.Expr{blank}, Value: copyOf(&py.Name{Id: py.Identifier("l")})},
+	}},
+
+	{"type I interface { M(x int, _ bool); N() }", []py.Stmt{
+		&py.ClassDef{
+			Name:          py.Identifier("I"),
+			Bases:         []py.Expr{&py.Attribute{Value: typingModule, Attr: py.Identifier("Protocol")}},
+			DecoratorList: []py.Expr{&py.Attribute{Value: typingModule, Attr: py.Identifier("runtime_checkable")}},
+			Body: []py.Stmt{
+				&py.FunctionDef{
+					Name: py.Identifier("M"),
+					Args: py.Arguments{Args: []py.Arg{{Arg: pySelf}, {Arg: x.Id}, {Arg: py.Identifier("arg")}}},
+					Body: []py.Stmt{&py.ExprStmt{Value: &py.Ellipsis{}}},
+				},
+				&py.FunctionDef{
+					Name: py.Identifier("N"),
+					Args: py.Arguments{Args: []py.Arg{{Arg: pySelf}}},
+					Body: []py.Stmt{&py.ExprStmt{Value: &py.Ellipsis{}}},
+				},
+			},
+		},
+	}},
+	{"// I has a doc comment.\ntype I interface {\n// M also has one.\nM()\nerror\n}", []py.Stmt{
+		&py.ClassDef{
+			Name:          py.Identifier("I"),
+			Bases:         []py.Expr{&py.Attribute{Value: typingModule, Attr: py.Identifier("Protocol")}},
+			DecoratorList: []py.Expr{&py.Attribute{Value: typingModule, Attr: py.Identifier("runtime_checkable")}},
+			Body: []py.Stmt{
+				&py.DocString{Lines: []string{"I has a doc comment."}},
+				&py.FunctionDef{
+					Name: py.Identifier("Error"),
+					Args: py.Arguments{Args: []py.Arg{{Arg: pySelf}}},
+					Body: []py.Stmt{&py.ExprStmt{Value: &py.Ellipsis{}}},
+				},
+				&py.FunctionDef{
+					Name: py.Identifier("M"),
+					Args: py.Arguments{Args: []py.Arg{{Arg: pySelf}}},
+					Body: []py.Stmt{
+						&py.DocString{Lines: []string{"M also has one."}},
+						&py.ExprStmt{Value: &py.Ellipsis{}},
+					},
+				},
+			},
+		},
+	}},
+	{"type V []int", []py.Stmt{&py.ClassDef{Name: py.Identifier("V"), Body: []py.Stmt{&py.Pass{}}}}},
+	{"type I interface {}", []py.Stmt{
+		&py.ClassDef{
+			Name:          py.Identifier("I"),
+			Bases:         []py.Expr{&py.Attribute{Value: typingModule, Attr: py.Identifier("Protocol")}},
+			DecoratorList: []py.Expr{&py.Attribute{Value: typingModule, Attr: py.Identifier("runtime_checkable")}},
+			Body:          []py.Stmt{&py.Pass{}},
 		},
 	}},
 
@@ -409,11 +1004,50 @@ var stmtTests = []struct {
 	}},
 
 	// Type switch
+	{"switch x { case 0: s(0); fallthrough; default: s(1); fallthrough; case 1: }", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{tag}, Value: x},
+		&py.If{
+			Test: &py.Compare{Left: tag, Comparators: []py.Expr{zero}, Ops: []py.CmpOp{py.Eq}},
+			Body: []py.Stmt{&py.Assign{Targets: []py.Expr{clause}, Value: zero}},
+			Orelse: []py.Stmt{&py.If{
+				Test:   &py.Compare{Left: tag, Comparators: []py.Expr{one}, Ops: []py.CmpOp{py.Eq}},
+				Body:   []py.Stmt{&py.Assign{Targets: []py.Expr{clause}, Value: two}},
+				Orelse: []py.Stmt{&py.Assign{Targets: []py.Expr{clause}, Value: one}},
+			}},
+		},
+		&py.If{
+			Test: &py.Compare{Left: clause, Comparators: []py.Expr{zero}, Ops: []py.CmpOp{py.Eq}},
+			Body: append(s(0), &py.Assign{Targets: []py.Expr{clause}, Value: one}),
+		},
+		&py.If{
+			Test: &py.Compare{Left: clause, Comparators: []py.Expr{one}, Ops: []py.CmpOp{py.Eq}},
+			Body: append(s(1), &py.Assign{Targets: []py.Expr{clause}, Value: two}),
+		},
+	}},
+	{"switch { case b0: fallthrough; case b1: s(0) }", []py.Stmt{
+		&py.If{
+			Test: b0,
+			Body: []py.Stmt{&py.Assign{Targets: []py.Expr{clause}, Value: zero}},
+			Orelse: []py.Stmt{&py.If{
+				Test:   b1,
+				Body:   []py.Stmt{&py.Assign{Targets: []py.Expr{clause}, Value: one}},
+				Orelse: []py.Stmt{&py.Assign{Targets: []py.Expr{clause}, Value: &py.Num{N: "-1"}}},
+			}},
+		},
+		&py.If{
+			Test: &py.Compare{Left: clause, Comparators: []py.Expr{zero}, Ops: []py.CmpOp{py.Eq}},
+			Body: []py.Stmt{&py.Assign{Targets: []py.Expr{clause}, Value: one}},
+		},
+		&py.If{
+			Test: &py.Compare{Left: clause, Comparators: []py.Expr{one}, Ops: []py.CmpOp{py.Eq}},
+			Body: s(0),
+		},
+	}},
 	{"switch s(0); obj.(type) { default: s(1); case T: s(2); case U: s(3)}", []py.Stmt{
 		s(0)[0],
 		&py.Assign{
 			Targets: []py.Expr{tag},
-			Value:   &py.Call{Func: pyType, Args: []py.Expr{obj}},
+			Value:   callRuntime("typeOf", obj),
 		},
 		&py.If{
 			Test: &py.Compare{Left: tag, Comparators: []py.Expr{T}, Ops: []py.CmpOp{py.Eq}},
@@ -429,21 +1063,22 @@ var stmtTests = []struct {
 	}},
 	{"switch s(0); y := obj.(type) { default: s(1, y); case T: s(2, y); case U: s(3, y)}", []py.Stmt{
 		s(0)[0],
+		&py.Assign{Targets: []py.Expr{y}, Value: obj},
 		&py.Assign{
-			Targets: []py.Expr{y},
-			Value:   &py.Call{Func: pyType, Args: []py.Expr{obj}},
+			Targets: []py.Expr{tag},
+			Value:   callRuntime("typeOf", y),
 		},
 		&py.If{
-			Test: &py.Compare{Left: y, Comparators: []py.Expr{T}, Ops: []py.CmpOp{py.Eq}},
+			Test: &py.Compare{Left: tag, Comparators: []py.Expr{T}, Ops: []py.CmpOp{py.Eq}},
 			Body: append([]py.Stmt{
-				&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("y2")}}, Value: y}},
-				s(2, &py.Name{Id: py.Identifier("y2")})...),
+				&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("y2")}}, Value: callRuntime("valueOf", y)}},
+				s(2, iface(T, copyOf(&py.Name{Id: py.Identifier("y2")})))...),
 			Orelse: []py.Stmt{
 				&py.If{
-					Test: &py.Compare{Left: y, Comparators: []py.Expr{U}, Ops: []py.CmpOp{py.Eq}},
+					Test: &py.Compare{Left: tag, Comparators: []py.Expr{U}, Ops: []py.CmpOp{py.Eq}},
 					Body: append([]py.Stmt{
-						&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("y3")}}, Value: y}},
-						s(3, &py.Name{Id: py.Identifier("y3")})...),
+						&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("y3")}}, Value: callRuntime("valueOf", y)}},
+						s(3, iface(U, copyOf(&py.Name{Id: py.Identifier("y3")})))...),
 					Orelse: append([]py.Stmt{
 						&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("y1")}}, Value: y}},
 						s(1, &py.Name{Id: py.Identifier("y1")})...),
@@ -451,31 +1086,344 @@ var stmtTests = []struct {
 			},
 		},
 	}},
+	{"switch obj.(type) { case nil: s(0); case error: s(1); case int, string: s(2) }", []py.Stmt{
+		&py.Assign{
+			Targets: []py.Expr{tag},
+			Value:   callRuntime("typeOf", obj),
+		},
+		&py.If{
+			Test: &py.Compare{Left: tag, Comparators: []py.Expr{pyNone}, Ops: []py.CmpOp{py.Is}},
+			Body: s(0),
+			Orelse: []py.Stmt{
+				&py.If{
+					Test: callRuntime("implements", tag, runtimeAttr("error")),
+					Body: s(1),
+					Orelse: []py.Stmt{
+						&py.If{
+							Test: &py.BoolOpExpr{Op: py.Or, Values: []py.Expr{
+								&py.Compare{Left: tag, Comparators: []py.Expr{runtimeAttr("int")}, Ops: []py.CmpOp{py.Eq}},
+								&py.Compare{Left: tag, Comparators: []py.Expr{runtimeAttr("string")}, Ops: []py.CmpOp{py.Eq}},
+							}},
+							Body: s(2),
+						},
+					},
+				},
+			},
+		},
+	}},
+	{"switch y := obj.(type) { case nil, T: s(y); case error: s(y) }", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{y}, Value: obj},
+		&py.Assign{
+			Targets: []py.Expr{tag},
+			Value:   callRuntime("typeOf", y),
+		},
+		&py.If{
+			Test: &py.BoolOpExpr{Op: py.Or, Values: []py.Expr{
+				&py.Compare{Left: tag, Comparators: []py.Expr{pyNone}, Ops: []py.CmpOp{py.Is}},
+				&py.Compare{Left: tag, Comparators: []py.Expr{T}, Ops: []py.CmpOp{py.Eq}},
+			}},
+			Body: append([]py.Stmt{
+				&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("y1")}}, Value: y}},
+				s(&py.Name{Id: py.Identifier("y1")})...),
+			Orelse: []py.Stmt{
+				&py.If{
+					Test: callRuntime("implements", tag, runtimeAttr("error")),
+					Body: append([]py.Stmt{
+						&py.Assign{Targets: []py.Expr{&py.Name{Id: py.Identifier("y2")}}, Value: y}},
+						s(&py.Name{Id: py.Identifier("y2")})...),
+				},
+			},
+		},
+	}},
 	{"switch obj.(type) { default: s(0)}", []py.Stmt{
 		&py.Assign{
 			Targets: []py.Expr{tag},
-			Value:   &py.Call{Func: pyType, Args: []py.Expr{obj}},
+			Value:   callRuntime("typeOf", obj),
 		},
 		s(0)[0],
 	}},
 	{"switch obj.(type) {}", []py.Stmt{
 		&py.Assign{
 			Targets: []py.Expr{tag},
-			Value:   &py.Call{Func: pyType, Args: []py.Expr{obj}},
+			Value:   callRuntime("typeOf", obj),
 		},
 	}},
 
-	// Builtin functions
-	{"delete(m, y)", []py.Stmt{
-		&py.Try{
-			Body: []py.Stmt{
-				&py.Delete{Targets: []py.Expr{&py.Subscript{Value: m, Slice: &py.Index{Value: y}}}},
+	// Labeled break and continue, and breaks out of switch and select
+	{"L: for { for { continue L } }", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{continueL}, Value: pyFalse},
+		&py.While{Test: pyTrue, Body: []py.Stmt{
+			&py.While{Test: pyTrue, Body: []py.Stmt{
+				&py.Assign{Targets: []py.Expr{continueL}, Value: pyTrue},
+				&py.Break{},
+			}},
+			&py.If{Test: continueL, Body: []py.Stmt{
+				&py.Assign{Targets: []py.Expr{continueL}, Value: pyFalse},
+				&py.Continue{},
+			}},
+		}},
+	}},
+	{"L: for { for { break L } }", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{breakL}, Value: pyFalse},
+		&py.While{Test: pyTrue, Body: []py.Stmt{
+			&py.While{Test: pyTrue, Body: []py.Stmt{
+				&py.Assign{Targets: []py.Expr{breakL}, Value: pyTrue},
+				&py.Break{},
+			}},
+			&py.If{Test: breakL, Body: []py.Stmt{&py.Break{}}},
+		}},
+	}},
+	{"for { switch { case b0: continue } }", []py.Stmt{
+		&py.While{Test: pyTrue, Body: []py.Stmt{
+			&py.If{Test: b0, Body: []py.Stmt{&py.Continue{}}},
+		}},
+	}},
+	{"for { switch { case b0: break; default: continue } }", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{continueLoop}, Value: pyFalse},
+		&py.While{Test: pyTrue, Body: []py.Stmt{
+			&py.While{Test: pyTrue, Body: []py.Stmt{
+				&py.If{
+					Test: b0,
+					Body: []py.Stmt{&py.Break{}},
+					Orelse: []py.Stmt{
+						&py.Assign{Targets: []py.Expr{continueLoop}, Value: pyTrue},
+						&py.Break{},
+					},
+				},
+				&py.Break{},
+			}},
+			&py.If{Test: continueLoop, Body: []py.Stmt{
+				&py.Assign{Targets: []py.Expr{continueLoop}, Value: pyFalse},
+				&py.Continue{},
+			}},
+		}},
+	}},
+	{"switch obj.(type) { case int: break }", []py.Stmt{
+		&py.While{Test: pyTrue, Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{tag}, Value: callRuntime("typeOf", obj)},
+			&py.If{
+				Test: &py.Compare{Left: tag, Comparators: []py.Expr{runtimeAttr("int")}, Ops: []py.CmpOp{py.Eq}},
+				Body: []py.Stmt{&py.Break{}},
 			},
-			Handlers: []py.ExceptHandler{
-				{Typ: &py.Name{Id: py.Identifier("KeyError")},
-					Body: []py.Stmt{&py.Pass{}}},
+			&py.Break{},
+		}},
+	}},
+	{"L: switch { case b0: for { break L } }", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{breakL}, Value: pyFalse},
+		&py.While{Test: pyTrue, Body: []py.Stmt{
+			&py.If{Test: b0, Body: []py.Stmt{
+				&py.While{Test: pyTrue, Body: []py.Stmt{
+					&py.Assign{Targets: []py.Expr{breakL}, Value: pyTrue},
+					&py.Break{},
+				}},
+				&py.If{Test: breakL, Body: []py.Stmt{&py.Break{}}},
+			}},
+			&py.Break{},
+		}},
+	}},
+	{"L: for { select { default: break L } }", []py.Stmt{
+		&py.While{Test: pyTrue, Body: []py.Stmt{&py.Break{}}},
+	}},
+
+	// Goto statements
+	{"{ s(0); L: s(1); if b0 { goto L } }", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{label}, Value: zero},
+		&py.While{Test: pyTrue, Body: []py.Stmt{
+			&py.If{Test: labelLtE(0), Body: s(0)},
+			&py.If{Test: labelLtE(1), Body: append(s(1),
+				&py.If{Test: b0, Body: []py.Stmt{
+					&py.Assign{Targets: []py.Expr{label}, Value: one},
+					&py.Continue{},
+				}},
+			)},
+			&py.Break{},
+		}},
+	}},
+	{"{ goto M; s(0); L: M: s(1); goto L }", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{label}, Value: zero},
+		&py.While{Test: pyTrue, Body: []py.Stmt{
+			&py.If{Test: labelLtE(0), Body: append([]py.Stmt{
+				&py.Assign{Targets: []py.Expr{label}, Value: two},
+				&py.Continue{},
+			}, s(0)...)},
+			&py.If{Test: labelLtE(2), Body: append(s(1),
+				&py.Assign{Targets: []py.Expr{label}, Value: one},
+				&py.Continue{},
+			)},
+			&py.Break{},
+		}},
+	}},
+	{"{ for { goto L }; L: s(0) }", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{label}, Value: zero},
+		&py.Assign{Targets: []py.Expr{jump}, Value: pyFalse},
+		&py.While{Test: pyTrue, Body: []py.Stmt{
+			&py.If{Test: labelLtE(0), Body: []py.Stmt{
+				&py.While{Test: pyTrue, Body: []py.Stmt{
+					&py.Assign{Targets: []py.Expr{label}, Value: one},
+					&py.Assign{Targets: []py.Expr{jump}, Value: pyTrue},
+					&py.Break{},
+				}},
+				&py.If{Test: jump, Body: []py.Stmt{
+					&py.Assign{Targets: []py.Expr{jump}, Value: pyFalse},
+					&py.Continue{},
+				}},
+			}},
+			&py.If{Test: labelLtE(1), Body: s(0)},
+			&py.Break{},
+		}},
+	}},
+	{"for { L: if b0 { goto L }; if b1 { continue }; break }", []py.Stmt{
+		&py.Assign{Targets: []py.Expr{breakLoop}, Value: pyFalse},
+		&py.Assign{Targets: []py.Expr{continueLoop}, Value: pyFalse},
+		&py.While{Test: pyTrue, Body: []py.Stmt{
+			&py.Assign{Targets: []py.Expr{label}, Value: zero},
+			&py.While{Test: pyTrue, Body: []py.Stmt{
+				&py.If{Test: labelLtE(0), Body: []py.Stmt{
+					&py.If{Test: b0, Body: []py.Stmt{
+						&py.Assign{Targets: []py.Expr{label}, Value: zero},
+						&py.Continue{},
+					}},
+					&py.If{Test: b1, Body: []py.Stmt{
+						&py.Assign{Targets: []py.Expr{continueLoop}, Value: pyTrue},
+						&py.Break{},
+					}},
+					&py.Assign{Targets: []py.Expr{breakLoop}, Value: pyTrue},
+					&py.Break{},
+				}},
+				&py.Break{},
+			}},
+			&py.If{Test: continueLoop, Body: []py.Stmt{
+				&py.Assign{Targets: []py.Expr{continueLoop}, Value: pyFalse},
+				&py.Continue{},
+			}},
+			&py.If{Test: breakLoop, Body: []py.Stmt{&py.Break{}}},
+		}},
+	}},
+
+	// Go statements
+	{"go f1(x)", []py.Stmt{
+		&py.ExprStmt{Value: &py.Call{
+			Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("go")},
+			Args: []py.Expr{f1, x},
+		}},
+	}},
+	{"go close(ch)", []py.Stmt{
+		&py.ExprStmt{Value: &py.Call{
+			Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("go")},
+			Args: []py.Expr{&py.Attribute{Value: ch, Attr: py.Identifier("close")}},
+		}},
+	}},
+	{"go func(y int) { s(y) }(x)", []py.Stmt{
+		&py.FunctionDef{
+			Name: py.Identifier("func"),
+			Args: py.Arguments{Args: []py.Arg{{Arg: y.Id}}},
+			Body: s(y),
+		},
+		&py.ExprStmt{Value: &py.Call{
+			Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("go")},
+			Args: []py.Expr{&py.Name{Id: py.Identifier("func")}, x},
+		}},
+	}},
+
+	// Send statements
+	{"ch <- x", []py.Stmt{
+		&py.ExprStmt{Value: &py.Call{
+			Func: &py.Attribute{Value: ch, Attr: py.Identifier("send")},
+			Args: []py.Expr{x},
+		}},
+	}},
+
+	// Select statements
+	{"select { case tch <- t0: }", []py.Stmt{
+		&py.Assign{
+			Targets: []py.Expr{sel, blank, blank},
+			Value: &py.Call{
+				Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("select")},
+				Args: []py.Expr{
+					&py.List{Elts: []py.Expr{callRuntime("selectSend", tch, copyOf(t0))}},
+					pyTrue,
+				},
 			},
 		},
+		&py.If{
+			Test: &py.Compare{Left: sel, Ops: []py.CmpOp{py.Eq}, Comparators: []py.Expr{zero}},
+			Body: []py.Stmt{&py.Pass{}},
+		},
+	}},
+	{"select { case ch <- x: s(0); case x, b0 = <-ch: s(1); default: s(2) }", []py.Stmt{
+		&py.Assign{
+			Targets: []py.Expr{sel, recv, recvOk},
+			Value: &py.Call{
+				Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("select")},
+				Args: []py.Expr{
+					&py.List{Elts: []py.Expr{
+						&py.Call{
+							Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("selectSend")},
+							Args: []py.Expr{ch, x},
+						},
+						&py.Call{
+							Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("selectRecv")},
+							Args: []py.Expr{ch},
+						},
+					}},
+					pyFalse,
+				},
+			},
+		},
+		&py.If{
+			Test: &py.Compare{Left: sel, Ops: []py.CmpOp{py.Eq}, Comparators: []py.Expr{zero}},
+			Body: s(0),
+			Orelse: []py.Stmt{&py.If{
+				Test: &py.Compare{Left: sel, Ops: []py.CmpOp{py.Eq}, Comparators: []py.Expr{one}},
+				Body: append([]py.Stmt{&py.Assign{
+					Targets: []py.Expr{x, b0},
+					Value:   &py.Tuple{Elts: []py.Expr{recv, recvOk}},
+				}}, s(1)...),
+				Orelse: s(2),
+			}},
+		},
+	}},
+	{"select { case <-ch: }", []py.Stmt{
+		&py.Assign{
+			Targets: []py.Expr{sel, blank, blank},
+			Value: &py.Call{
+				Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("select")},
+				Args: []py.Expr{
+					&py.List{Elts: []py.Expr{
+						&py.Call{
+							Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("selectRecv")},
+							Args: []py.Expr{ch},
+						},
+					}},
+					pyTrue,
+				},
+			},
+		},
+		&py.If{
+			Test: &py.Compare{Left: sel, Ops: []py.CmpOp{py.Eq}, Comparators: []py.Expr{zero}},
+			Body: []py.Stmt{&py.Pass{}},
+		},
+	}},
+	{"select { default: s(0) }", s(0)},
+
+	// Builtin functions
+	{"panic(x)", []py.Stmt{
+		&py.Raise{Exc: &py.Call{
+			Func: &py.Attribute{Value: runtimeModule, Attr: py.Identifier("GoPanic")},
+			Args: []py.Expr{x},
+		}},
+	}},
+	{"panic(t0)", []py.Stmt{
+		&py.Raise{Exc: callRuntime("GoPanic", iface(T, copyOf(t0)))},
+	}},
+	{"println(x, obj)", []py.Stmt{
+		&py.ExprStmt{Value: callRuntime("println", x, callRuntime("printIface", obj))},
+	}},
+	{"close(ch)", []py.Stmt{
+		&py.ExprStmt{Value: &py.Call{Func: &py.Attribute{Value: ch, Attr: py.Identifier("close")}}},
+	}},
+	{"delete(m, y)", []py.Stmt{
+		&py.ExprStmt{Value: callRuntime("delete", m, y)},
 	}},
 }
 
@@ -499,7 +1447,9 @@ func TestStmt(t *testing.T) {
 			}
 
 			c := NewCompiler(&pkg.Info, nil)
-			goStmt := file.Scope.Lookup("main").Decl.(*ast.FuncDecl).Body.List[0]
+			body := file.Scope.Lookup("main").Decl.(*ast.FuncDecl).Body
+			c.collectLabels(body)
+			goStmt := body.List[0]
 			pyStmts := c.compileStmt(goStmt)
 			if !reflect.DeepEqual(pyStmts, test.python) {
 				t.Errorf("%q\nwant:\n%s\ngot:\n%s\n", test.golang, pythonCode(test.python), pythonCode(pyStmts))
@@ -10,13 +10,17 @@ import (
 	"go/build"
 	"go/parser"
 	"golang.org/x/tools/go/loader"
+	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
 )
 
 var (
 	dumpGoAST     = flag.Bool("g", false, "Dump the Go syntax tree to stdout")
 	dumpPythonAST = flag.Bool("p", false, "Dump the Python syntax tree to stdout")
-	output        = flag.String("o", "", "Write the Python module to this file")
+	output        = flag.String("o", "", "Write the Python module to this file and the runtime module to runtime.py in the same directory")
+	goVersion     = flag.String("lang", "", "Go language version such as go1.21 (default is the go version of the package's module)")
 )
 
 const (
@@ -32,6 +36,28 @@ func usage() {
 	flag.PrintDefaults()
 }
 
+// moduleGoVersion returns the Go version declared by the go.mod file of the
+// module containing dir, or "" if dir is not in a module.
+func moduleGoVersion(dir string) string {
+	for {
+		data, err := ioutil.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			for _, line := range strings.Split(string(data), "\n") {
+				if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "go" {
+					return "go" + fields[1]
+				}
+			}
+			// A module without a go directive is assumed to be Go 1.16
+			return "go1.16"
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
 func main() {
 	flag.Usage = usage
 	flag.Parse()
@@ -71,6 +97,10 @@ func main() {
 		}
 
 		c := compiler.NewCompiler(&pkg.Info, program.Fset)
+		c.GoVersion = *goVersion
+		if c.GoVersion == "" && len(pkg.Files) > 0 {
+			c.GoVersion = moduleGoVersion(filepath.Dir(program.Fset.File(pkg.Files[0].Pos()).Name()))
+		}
 		module := c.CompileFiles(pkg.Files)
 
 		if *dumpPythonAST {
@@ -87,5 +117,14 @@ func main() {
 		}
 		pyWriter := py.NewWriter(writer)
 		pyWriter.WriteModule(module)
+
+		if *output != "" {
+			runtimePath := filepath.Join(filepath.Dir(*output), "runtime.py")
+			err = ioutil.WriteFile(runtimePath, []byte(compiler.RuntimeSource), 0666)
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				os.Exit(errOutput)
+			}
+		}
 	}
 }
@@ -64,6 +64,14 @@ func (w *Writer) writeStmt(stmt Stmt) {
 		w.comment(s)
 	case *DocString:
 		w.docstring(s)
+	case *Import:
+		w.importStmt(s)
+	case *Raise:
+		w.raise(s)
+	case *Global:
+		w.names("global ", s.Names)
+	case *Nonlocal:
+		w.names("nonlocal ", s.Names)
 	default:
 		panic(fmt.Sprintf("unknown Stmt: %T", stmt))
 	}
@@ -84,6 +92,31 @@ func (w *Writer) docstring(s *DocString) {
 	w.write(`"""`)
 }
 
+func (w *Writer) importStmt(s *Import) {
+	w.write("import ")
+	for i, alias := range s.Names {
+		if i > 0 {
+			w.comma()
+		}
+		w.identifier(alias.Name)
+		if alias.Asname != nil {
+			w.write(" as ")
+			w.identifier(*alias.Asname)
+		}
+	}
+}
+
+// names writes a statement consisting of a keyword and a list of names.
+func (w *Writer) names(keyword string, names []Identifier) {
+	w.write(keyword)
+	for i, name := range names {
+		if i > 0 {
+			w.comma()
+		}
+		w.identifier(name)
+	}
+}
+
 func (w *Writer) ret(s *Return) {
 	if s.Value != nil {
 		w.write("return ")
@@ -130,9 +163,9 @@ func (w *Writer) ifStmt(s *If) {
 	w.indent()
 	w.writeStmts(s.Body)
 	w.dedent()
-	if s.Orelse != nil {
+	if len(s.Orelse) > 0 {
 		w.newline()
-		if elif, ok := s.Orelse[0].(*If); ok {
+		if elif, ok := s.Orelse[0].(*If); ok && len(s.Orelse) == 1 {
 			w.write("el")
 			w.writeStmt(elif)
 		} else {
@@ -197,6 +230,18 @@ func (w *Writer) try(s *Try) {
 	}
 }
 
+func (w *Writer) raise(s *Raise) {
+	w.write("raise")
+	if s.Exc != nil {
+		w.write(" ")
+		w.WriteExpr(s.Exc)
+		if s.Cause != nil {
+			w.write(" from ")
+			w.WriteExpr(s.Cause)
+		}
+	}
+}
+
 func (w *Writer) augAssign(s *AugAssign) {
 	w.WriteExpr(s.Target)
 	switch s.Op {
@@ -350,6 +395,8 @@ func (w *Writer) writeExprPrec(expr Expr, parentPrec int) {
 		w.starred(e)
 	case *Lambda:
 		w.lambda(e)
+	case *Ellipsis:
+		w.write("...")
 	default:
 		panic(fmt.Sprintf("unknown Expr: %T", expr))
 	}
@@ -387,14 +434,17 @@ func (w *Writer) listComp(e *ListComp) {
 }
 
 func (w *Writer) boolOpExpr(e *BoolOpExpr) {
-	w.writeExprPrec(e.Values[0], e.Precedence())
-	switch e.Op {
-	case Or:
-		w.write(" or ")
-	case And:
-		w.write(" and ")
+	for i, value := range e.Values {
+		if i > 0 {
+			switch e.Op {
+			case Or:
+				w.write(" or ")
+			case And:
+				w.write(" and ")
+			}
+		}
+		w.writeExprPrec(value, e.Precedence())
 	}
-	w.writeExprPrec(e.Values[1], e.Precedence())
 }
 
 func (w *Writer) unaryOpExpr(e *UnaryOpExpr) {
@@ -522,21 +572,56 @@ func (w *Writer) writeCmpOp(op CmpOp) {
 }
 
 func (w *Writer) args(args Arguments) {
-	defaultOffset := len(args.Args) - len(args.Defaults)
-	for i, arg := range args.Args {
-		if i > 0 {
+	first := true
+	sep := func() {
+		if !first {
 			w.comma()
 		}
+		first = false
+	}
+	defaultOffset := len(args.Args) - len(args.Defaults)
+	for i, arg := range args.Args {
+		sep()
 		w.identifier(arg.Arg)
 		if i >= defaultOffset {
 			w.write("=")
 			w.WriteExpr(args.Defaults[i-defaultOffset])
 		}
 	}
+	if args.Vararg != nil {
+		sep()
+		w.write("*")
+		w.identifier(args.Vararg.Arg)
+	} else if len(args.Kwonlyargs) > 0 {
+		sep()
+		w.write("*")
+	}
+	for i, arg := range args.Kwonlyargs {
+		sep()
+		w.identifier(arg.Arg)
+		if args.KwDefaults[i] != nil {
+			w.write("=")
+			w.WriteExpr(args.KwDefaults[i])
+		}
+	}
+	if args.Kwarg != nil {
+		sep()
+		w.write("**")
+		w.identifier(args.Kwarg.Arg)
+	}
+}
+
+func (w *Writer) decorators(decorators []Expr) {
+	for _, decorator := range decorators {
+		w.write("@")
+		w.WriteExpr(decorator)
+		w.newline()
+	}
 }
 
 func (w *Writer) functionDef(s *FunctionDef) {
 	w.newline()
+	w.decorators(s.DecoratorList)
 	w.write("def ")
 	w.identifier(s.Name)
 	w.beginParen()
@@ -555,6 +640,7 @@ func (w *Writer) functionDef(s *FunctionDef) {
 
 func (w *Writer) classDef(s *ClassDef) {
 	w.newline()
+	w.decorators(s.DecoratorList)
 	w.write("class ")
 	w.identifier(s.Name)
 	if len(s.Bases) > 0 {
@@ -76,6 +76,7 @@ func TestExpr(t *testing.T) {
 		{tup(lambda(args(a), b), c), "lambda a: b, c"},
 		{lambda(args(a), tup(b, c)), "lambda a: (b, c)"},
 		{call(a, star(b)), "a(*b)"},
+		{&BoolOpExpr{Op: And, Values: []Expr{a, b, c}}, "a and b and c"},
 	}
 	for _, test := range tests {
 		t.Run(test.want, func(t *testing.T) {
@@ -89,3 +90,41 @@ func TestExpr(t *testing.T) {
 		})
 	}
 }
+
+func TestStmt(t *testing.T) {
+	tests := []struct {
+		stmt Stmt
+		want string
+	}{
+		{&Import{Names: []Alias{{Name: a.Id}}}, "import a"},
+		{&Import{Names: []Alias{{Name: a.Id, Asname: &b.Id}, {Name: c.Id}}}, "import a as b, c"},
+		{&Global{Names: []Identifier{a.Id}}, "global a"},
+		{&Nonlocal{Names: []Identifier{a.Id}}, "nonlocal a"},
+		{&Nonlocal{Names: []Identifier{a.Id, b.Id}}, "nonlocal a, b"},
+		{&Raise{}, "raise"},
+		{&Raise{Exc: &Call{Func: a}}, "raise a()"},
+		{&Raise{Exc: a, Cause: b}, "raise a from b"},
+		{&ExprStmt{Value: &Ellipsis{}}, "..."},
+		{&ClassDef{Name: a.Id, Body: []Stmt{&Pass{}}, DecoratorList: []Expr{b}}, "\n@b\nclass a:\n    pass"},
+		{&FunctionDef{Name: a.Id, Body: []Stmt{&Pass{}}, DecoratorList: []Expr{b, c}}, "\n@b\n@c\ndef a():\n    pass"},
+		{&FunctionDef{Name: a.Id, Args: Arguments{Args: []Arg{{Arg: b.Id}}, Kwonlyargs: []Arg{{Arg: c.Id}}, KwDefaults: []Expr{c}}, Body: []Stmt{&Pass{}}},
+			"\ndef a(b, *, c=c):\n    pass"},
+		{&FunctionDef{Name: a.Id, Args: Arguments{Vararg: &Arg{Arg: b.Id}, Kwonlyargs: []Arg{{Arg: c.Id}}, KwDefaults: []Expr{nil}}, Body: []Stmt{&Pass{}}},
+			"\ndef a(*b, c):\n    pass"},
+		{&If{Test: a, Body: []Stmt{&Pass{}}, Orelse: []Stmt{&If{Test: b, Body: []Stmt{&Break{}}}}},
+			"if a:\n    pass\nelif b:\n    break"},
+		{&If{Test: a, Body: []Stmt{&Pass{}}, Orelse: []Stmt{&If{Test: b, Body: []Stmt{&Break{}}}, &Pass{}}},
+			"if a:\n    pass\nelse:\n    if b:\n        break\n    pass"},
+	}
+	for _, test := range tests {
+		t.Run(test.want, func(t *testing.T) {
+			var buf bytes.Buffer
+			w := NewWriter(&buf)
+			w.writeStmt(test.stmt)
+			got := buf.String()
+			if test.want != got {
+				t.Errorf("want %q got %q", test.want, got)
+			}
+		})
+	}
+}